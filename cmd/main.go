@@ -1,27 +1,61 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/naval1525/Social_Media_Backend/internal/config"
 	"github.com/naval1525/Social_Media_Backend/internal/database"
 	"github.com/naval1525/Social_Media_Backend/internal/handler"
+	"github.com/naval1525/Social_Media_Backend/internal/health"
+	"github.com/naval1525/Social_Media_Backend/internal/jwtkeys"
+	"github.com/naval1525/Social_Media_Backend/internal/logging"
+	"github.com/naval1525/Social_Media_Backend/internal/mailer"
+	"github.com/naval1525/Social_Media_Backend/internal/metrics"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/ratelimit"
 	"github.com/naval1525/Social_Media_Backend/internal/repository"
 	"github.com/naval1525/Social_Media_Backend/internal/service"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+	"github.com/naval1525/Social_Media_Backend/internal/worker"
 )
 
 func main() {
     // Load configuration (env/config file). No defaults inside code; must be provided.
     cfg, err := config.Load()
     if err != nil {
-        log.Fatal("Failed to load config:", err)
+        fatal("failed to load config", err)
     }
 
+	// Configure structured logging
+	logger, err := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fatal("failed to configure logging", err)
+	}
+	slog.SetDefault(logger)
+
+	// Configure tracing before anything that might start spans
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.OTLPEndpoint,
+		Insecure:    cfg.Tracing.OTLPInsecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		Environment: cfg.App.Env,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		fatal("failed to configure tracing", err)
+	}
+
 	// Connect to database
     // Create DB connection from config
     var db *database.DB
@@ -38,67 +72,374 @@ func main() {
         })
     }
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("failed to connect to database", err)
 	}
-	defer db.Close()
+
+	// Export connection pool statistics
+	metrics.RegisterDB(db.DB, "postgres")
 
 	// Run migrations
 	if err := db.Migrate(); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+		fatal("failed to run migrations", err)
 	}
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.DB)
+	postRepo := repository.NewPostRepository(db.DB)
+	followRepo := repository.NewFollowRepository(db.DB)
+	likeRepo := repository.NewLikeRepository(db.DB)
+	commentRepo := repository.NewCommentRepository(db.DB)
+	sessionRepo := repository.NewSessionRepository(db.DB)
+	userTokenRepo := repository.NewUserTokenRepository(db.DB)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db.DB)
+	moderationRepo := repository.NewModerationRepository(db.DB)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db.DB)
 
-	// Initialize services
-    jwtSecret := cfg.JWTSecret
+	// Initialize mailer
+	var mail mailer.Mailer = mailer.NewLogMailer(cfg.Mail.From, cfg.Mail.Dir)
+	if cfg.Mail.Driver == "smtp" {
+		mail = mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.Mail.Host,
+			Port:     cfg.Mail.Port,
+			Username: cfg.Mail.Username,
+			Password: cfg.Mail.Password,
+			From:     cfg.Mail.From,
+		})
+	}
+
+	// Load JWT signing keys, falling back to the shared secret
+	keys := jwtkeys.NewHMACKeyRing(cfg.JWTSecret)
+	if cfg.Auth.KeysDir != "" {
+		keys, err = jwtkeys.LoadDir(cfg.Auth.KeysDir, cfg.Auth.ActiveKeyID)
+		if err != nil {
+			fatal("failed to load JWT signing keys", err)
+		}
+	}
 
-	userService := service.NewUserService(userRepo, jwtSecret)
+	// Initialize services
+	authService := service.NewAuthService(sessionRepo, userRepo, service.TokenConfig{
+		Keys:            keys,
+		Issuer:          cfg.Auth.Issuer,
+		Audience:        cfg.Auth.Audience,
+		AccessTokenTTL:  cfg.Auth.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+	})
+	accountService := service.NewAccountService(userRepo, userTokenRepo, authService, mail, service.AccountConfig{
+		BaseURL:               cfg.App.BaseURL,
+		VerificationTokenTTL:  cfg.Auth.VerificationTokenTTL,
+		PasswordResetTokenTTL: cfg.Auth.PasswordResetTokenTTL,
+	})
+	loginCfg := service.LoginConfig{
+		MaxAttempts:     cfg.Auth.LoginMaxAttempts,
+		LockoutDuration: cfg.Auth.LoginLockoutDuration,
+		BackoffBase:     cfg.Auth.LoginBackoffBase,
+		IPMaxAttempts:   cfg.Auth.LoginIPMaxAttempts,
+		IPWindow:        cfg.Auth.LoginIPWindow,
+	}
+	userService := service.NewUserService(userRepo, followRepo, postRepo, loginAttemptRepo, authService, accountService, loginCfg, service.UsernameConfig{
+		ChangeCooldown: cfg.App.UsernameChangeCooldown,
+		HoldPeriod:     cfg.App.UsernameHoldPeriod,
+	}, service.DeletionConfig{
+		GracePeriod: cfg.App.AccountDeletionGracePeriod,
+	})
+	twoFactorService := service.NewTwoFactorService(userRepo, recoveryCodeRepo, loginAttemptRepo, authService, accountService, loginCfg)
+	postService := service.NewPostService(postRepo, likeRepo, userRepo, cfg.App.RequireVerifiedEmail)
+	followService := service.NewFollowService(followRepo, userRepo)
+	commentService := service.NewCommentService(commentRepo, postRepo)
+	adminService := service.NewAdminService(userRepo, moderationRepo, authService)
 
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userService)
+	postHandler := handler.NewPostHandler(postService)
+	followHandler := handler.NewFollowHandler(followService)
+	commentHandler := handler.NewCommentHandler(commentService)
+	authHandler := handler.NewAuthHandler(authService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	adminHandler := handler.NewAdminHandler(adminService)
+
+	// Readiness checks
+	checker := health.NewChecker(cfg.Server.ReadinessTimeout)
+	checker.Register("database", health.Database(db))
+	checker.Register("migrations", health.Migrations(db))
+	healthHandler := handler.NewHealthHandler(checker)
+
+	// Rate limiting
+	limits, err := newRateLimits(cfg.RateLimit)
+	if err != nil {
+		fatal("failed to configure rate limits", err)
+	}
+
+	// Client IPs behind a reverse proxy
+	trustedProxies, err := handler.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		fatal("failed to configure trusted proxies", err)
+	}
 
 	// Setup router
-	router := setupRouter(userHandler, userService)
+	router := setupRouter(userHandler, postHandler, followHandler, commentHandler, authHandler, accountHandler, twoFactorHandler, adminHandler, healthHandler, authService, limits, trustedProxies)
+
+	// Catch SIGINT/SIGTERM from here on so a deploy drains instead of dropping requests
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	// Start background jobs
+	workers := worker.NewRunner(
+		worker.Job{Name: "purge-stale-tokens", Interval: time.Hour, Run: accountService.PurgeStaleTokens},
+		worker.Job{Name: "purge-deleted-accounts", Interval: time.Hour, Run: userService.PurgeDeletedAccounts},
+	)
+	workers.Start()
 
 	// Start server
-    log.Printf("🚀 Server starting on port %s", cfg.Server.Port)
-    log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", cfg.Server.Port), router))
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	slog.Info("server starting", "port", cfg.Server.Port, "env", cfg.App.Env)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	// Wait for a shutdown signal. A second signal skips the graceful drain.
+	select {
+	case err := <-serverErr:
+		fatal("server stopped", err)
+	case <-signals.Done():
+	}
+	stopSignals()
+
+	// Shut down in dependency order: stop taking requests and let in-flight ones
+	// finish, then stop the jobs, flush traces and finally close the pool they
+	// all use. Everything shares one deadline.
+	slog.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("failed to drain connections", "error", err)
+	}
+	if err := workers.Stop(ctx); err != nil {
+		slog.Error("failed to stop background jobs", "error", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	if err := db.Close(); err != nil {
+		slog.Error("failed to close database", "error", err)
+	}
+	slog.Info("server stopped")
 }
 
-func setupRouter(userHandler *handler.UserHandler, userService service.UserService) *mux.Router {
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// rateLimits holds the rate limiting middleware for each route group
+type rateLimits struct {
+	auth, write, read mux.MiddlewareFunc
+}
+
+// newRateLimits builds the per-group limiters over a shared in-memory store.
+// When disabled every group gets a pass-through.
+func newRateLimits(cfg config.RateLimitConfig) (rateLimits, error) {
+	if !cfg.Enabled {
+		passThrough := func(next http.Handler) http.Handler { return next }
+		return rateLimits{auth: passThrough, write: passThrough, read: passThrough}, nil
+	}
+
+	store := ratelimit.NewMemoryStore()
+	limit := func(name, spec string) (mux.MiddlewareFunc, error) {
+		policy, err := ratelimit.ParsePolicy(name, spec)
+		if err != nil {
+			return nil, err
+		}
+		return handler.RateLimitMiddleware(store, policy), nil
+	}
+
+	var limits rateLimits
+	var err error
+	if limits.auth, err = limit("auth", cfg.Auth); err != nil {
+		return limits, err
+	}
+	if limits.write, err = limit("write", cfg.Write); err != nil {
+		return limits, err
+	}
+	if limits.read, err = limit("read", cfg.Read); err != nil {
+		return limits, err
+	}
+	return limits, nil
+}
+
+// uuidPattern restricts {id} route variables so they don't shadow static paths like /me
+const uuidPattern = "[0-9a-fA-F-]{36}"
+
+func setupRouter(
+	userHandler *handler.UserHandler,
+	postHandler *handler.PostHandler,
+	followHandler *handler.FollowHandler,
+	commentHandler *handler.CommentHandler,
+	authHandler *handler.AuthHandler,
+	accountHandler *handler.AccountHandler,
+	twoFactorHandler *handler.TwoFactorHandler,
+	adminHandler *handler.AdminHandler,
+	healthHandler *handler.HealthHandler,
+	authService service.AuthService,
+	limits rateLimits,
+	trustedProxies []*net.IPNet,
+) *mux.Router {
 	router := mux.NewRouter()
 
-	// Apply global middleware
-	router.Use(handler.CORSMiddleware)
+	// Apply global middleware. Unmatched requests skip router middleware, so
+	// the fallback handlers are wrapped to get a request ID, a log line and a span too.
+	clientIP := handler.ClientIPMiddleware(trustedProxies)
+	router.Use(clientIP)
+	router.Use(handler.RequestIDMiddleware)
 	router.Use(handler.LoggingMiddleware)
+	router.Use(handler.TracingMiddleware)
+	router.Use(handler.CORSMiddleware)
+	fallback := func(h http.Handler) http.Handler {
+		return clientIP(handler.RequestIDMiddleware(handler.LoggingMiddleware(handler.TracingMiddleware(h))))
+	}
+	router.NotFoundHandler = fallback(handler.NotFoundHandler())
+	router.MethodNotAllowedHandler = fallback(handler.MethodNotAllowedHandler())
+
+	// Orchestrator probes: liveness never touches dependencies, readiness checks them
+	router.HandleFunc("/livez", healthHandler.Livez).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.Readyz).Methods("GET")
+
+	// Prometheus metrics
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Public signing keys for other services verifying our tokens
+	router.HandleFunc("/.well-known/jwks.json", authHandler.JWKS).Methods("GET")
 
 	// API routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 
-	// Health check
-    api.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-        // use exported helper
-        type payload struct{ Status string `json:"status"` }
-        w.Header().Set("Content-Type", "application/json")
-        w.WriteHeader(http.StatusOK)
-        json.NewEncoder(w).Encode(map[string]string{"message": "API is running", "status": "healthy"})
-    }).Methods("GET")
+	// Health check, kept for existing clients; same report as /readyz
+	api.HandleFunc("/health", healthHandler.Readyz).Methods("GET")
 
-	// Auth routes (no authentication required)
+	// Auth routes (no authentication required). The strict limit keys on IP
+	// for every auth route, including the protected ones below.
 	auth := api.PathPrefix("/auth").Subrouter()
+	auth.Use(limits.auth)
 	auth.HandleFunc("/register", userHandler.Register).Methods("POST")
 	auth.HandleFunc("/login", userHandler.Login).Methods("POST")
+	auth.HandleFunc("/refresh", authHandler.Refresh).Methods("POST")
+	auth.HandleFunc("/logout", authHandler.Logout).Methods("POST")
+	auth.HandleFunc("/verify-email", accountHandler.VerifyEmail).Methods("POST")
+	auth.HandleFunc("/forgot-password", accountHandler.ForgotPassword).Methods("POST")
+	auth.HandleFunc("/reset-password", accountHandler.ResetPassword).Methods("POST")
+	auth.HandleFunc("/2fa/verify", twoFactorHandler.Verify).Methods("POST")
+
+	// Protected auth routes (authentication required)
+	protectedAuth := auth.PathPrefix("").Subrouter()
+	protectedAuth.Use(handler.AuthMiddleware(authService))
+	protectedAuth.HandleFunc("/logout-all", authHandler.LogoutAll).Methods("POST")
+	protectedAuth.HandleFunc("/verify-email/resend", accountHandler.ResendVerification).Methods("POST")
+	protectedAuth.HandleFunc("/2fa/enroll", twoFactorHandler.Enroll).Methods("POST")
+	protectedAuth.HandleFunc("/2fa/confirm", twoFactorHandler.Confirm).Methods("POST")
+	protectedAuth.HandleFunc("/2fa/disable", twoFactorHandler.Disable).Methods("POST")
 
 	// User routes
 	users := api.PathPrefix("/users").Subrouter()
-	users.HandleFunc("/{id}", userHandler.GetProfile).Methods("GET")
+
+	// Public user routes
+	publicUsers := users.PathPrefix("").Subrouter()
+	publicUsers.Use(limits.read)
+	publicUsers.HandleFunc("/{id:"+uuidPattern+"}/followers", followHandler.GetFollowers).Methods("GET")
+	publicUsers.HandleFunc("/{id:"+uuidPattern+"}/following", followHandler.GetFollowing).Methods("GET")
+
+	// Public user routes that personalize output when a token is present
+	viewerUsers := users.PathPrefix("").Subrouter()
+	viewerUsers.Use(handler.OptionalAuthMiddleware(authService))
+	viewerUsers.Use(limits.read)
+	viewerUsers.HandleFunc("/{id:"+uuidPattern+"}", userHandler.GetProfile).Methods("GET")
+	viewerUsers.HandleFunc("/by-username/{username}", userHandler.GetProfileByUsername).Methods("GET")
+	viewerUsers.HandleFunc("/{id:"+uuidPattern+"}/posts", postHandler.GetUserPosts).Methods("GET")
+
+	// The caller's own account (authentication required). Reads are kept off
+	// the write budget so polling the profile can't throttle real changes.
+	meUsers := users.PathPrefix("").Subrouter()
+	meUsers.Use(handler.AuthMiddleware(authService))
+	meUsers.Use(limits.read)
+	meUsers.HandleFunc("/me", userHandler.GetMyProfile).Methods("GET")
+	meUsers.HandleFunc("/me/logins", userHandler.GetLoginHistory).Methods("GET")
 
 	// Protected user routes (authentication required)
 	protectedUsers := users.PathPrefix("").Subrouter()
-	protectedUsers.Use(handler.AuthMiddleware(userService))
-	protectedUsers.HandleFunc("/me", userHandler.GetMyProfile).Methods("GET")
-	protectedUsers.HandleFunc("/me", userHandler.UpdateProfile).Methods("PUT")
+	protectedUsers.Use(handler.AuthMiddleware(authService))
+	protectedUsers.Use(limits.write)
+	// PUT is kept for older clients and takes the same merge patch as PATCH
+	protectedUsers.HandleFunc("/me", userHandler.UpdateProfile).Methods("PATCH", "PUT")
+	protectedUsers.HandleFunc("/me", userHandler.DeleteAccount).Methods("DELETE")
+	protectedUsers.HandleFunc("/me/username", userHandler.ChangeUsername).Methods("PUT")
+	protectedUsers.HandleFunc("/{id:"+uuidPattern+"}/follow", followHandler.Follow).Methods("POST")
+	protectedUsers.HandleFunc("/{id:"+uuidPattern+"}/follow", followHandler.Unfollow).Methods("DELETE")
+
+	// Public profile pages by handle, e.g. /api/v1/@jane
+	profiles := api.PathPrefix("").Subrouter()
+	profiles.Use(handler.OptionalAuthMiddleware(authService))
+	profiles.Use(limits.read)
+	profiles.HandleFunc("/@{username}", userHandler.GetProfileByUsername).Methods("GET")
+
+	// Post routes
+	posts := api.PathPrefix("/posts").Subrouter()
+
+	// Public post routes (is_liked is filled in when a token is present)
+	viewerPosts := posts.PathPrefix("").Subrouter()
+	viewerPosts.Use(handler.OptionalAuthMiddleware(authService))
+	viewerPosts.Use(limits.read)
+	viewerPosts.HandleFunc("/{id:"+uuidPattern+"}", postHandler.GetPost).Methods("GET")
+	viewerPosts.HandleFunc("/{id:"+uuidPattern+"}/comments", commentHandler.GetComments).Methods("GET")
+
+	// Protected post routes (authentication required)
+	protectedPosts := posts.PathPrefix("").Subrouter()
+	protectedPosts.Use(handler.AuthMiddleware(authService))
+	protectedPosts.Use(limits.write)
+	protectedPosts.HandleFunc("", postHandler.CreatePost).Methods("POST")
+	protectedPosts.HandleFunc("/{id:"+uuidPattern+"}", postHandler.UpdatePost).Methods("PUT")
+	protectedPosts.HandleFunc("/{id:"+uuidPattern+"}", postHandler.DeletePost).Methods("DELETE")
+	protectedPosts.HandleFunc("/{id:"+uuidPattern+"}/like", postHandler.LikePost).Methods("POST")
+	protectedPosts.HandleFunc("/{id:"+uuidPattern+"}/like", postHandler.UnlikePost).Methods("DELETE")
+	protectedPosts.HandleFunc("/{id:"+uuidPattern+"}/comments", commentHandler.CreateComment).Methods("POST")
+
+	// Feed routes (authentication required)
+	feed := api.PathPrefix("/feed").Subrouter()
+	feed.Use(handler.AuthMiddleware(authService))
+	feed.Use(limits.read)
+	feed.HandleFunc("", postHandler.GetFeed).Methods("GET")
+
+	// Comment routes (authentication required)
+	comments := api.PathPrefix("/comments").Subrouter()
+	comments.Use(handler.AuthMiddleware(authService))
+	comments.Use(limits.write)
+	comments.HandleFunc("/{id:"+uuidPattern+"}", commentHandler.DeleteComment).Methods("DELETE")
+
+	// Admin routes (authentication plus a per-route permission required)
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(handler.AuthMiddleware(authService))
+	admin.Use(limits.write)
+	requirePermission := func(permission model.Permission, h http.HandlerFunc) http.Handler {
+		return handler.RequirePermission(permission)(h)
+	}
+	admin.Handle("/users", requirePermission(model.PermissionListUsers, adminHandler.ListUsers)).Methods("GET")
+	admin.Handle("/users/{id:"+uuidPattern+"}/suspend", requirePermission(model.PermissionSuspendUsers, adminHandler.SuspendUser)).Methods("POST")
+	admin.Handle("/users/{id:"+uuidPattern+"}/unsuspend", requirePermission(model.PermissionSuspendUsers, adminHandler.UnsuspendUser)).Methods("POST")
+	admin.Handle("/users/{id:"+uuidPattern+"}/ban", requirePermission(model.PermissionBanUsers, adminHandler.BanUser)).Methods("POST")
+	admin.Handle("/users/{id:"+uuidPattern+"}/unban", requirePermission(model.PermissionBanUsers, adminHandler.UnbanUser)).Methods("POST")
+	admin.Handle("/users/{id:"+uuidPattern+"}/moderation", requirePermission(model.PermissionListUsers, adminHandler.GetModerationActions)).Methods("GET")
+	admin.Handle("/users/{id:"+uuidPattern+"}/role", requirePermission(model.PermissionManageRoles, adminHandler.ChangeRole)).Methods("PUT")
+	admin.Handle("/users/{id:"+uuidPattern+"}", requirePermission(model.PermissionDeleteUsers, adminHandler.DeleteUser)).Methods("DELETE")
 
 	return router
 }
@@ -0,0 +1,108 @@
+// Package apperr defines the domain errors shared by the repository, service
+// and handler layers. Each error has a kind that decides the HTTP status, a
+// stable code clients can branch on and a message that is safe to show them.
+package apperr
+
+import "errors"
+
+// Kinds of domain error. Match them with errors.Is, e.g.
+// errors.Is(err, apperr.ErrNotFound).
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrPreconditionFailed means the resource changed since the caller read it
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is a domain error. Declare them once as package-level sentinels and
+// wrap them with fmt.Errorf to add context; the code and message survive
+// wrapping while anything added around them stays out of responses.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches e's kind, so every not-found error is errors.Is ErrNotFound
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+// Conflict creates an error for a request that clashes with existing state
+func Conflict(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+// Validation creates an error for input the domain rejects
+func Validation(code, message string) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message}
+}
+
+// Forbidden creates an error for an action the caller isn't allowed to take
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+// PreconditionFailed creates an error for a conditional request whose
+// condition no longer holds
+func PreconditionFailed(code, message string) *Error {
+	return &Error{Kind: ErrPreconditionFailed, Code: code, Message: message}
+}
+
+// Unauthorized creates an error for missing or invalid credentials
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+// detailError attaches one extra fact about an error for the client, such as
+// when a suspension ends. It unwraps to the error it annotates.
+type detailError struct {
+	err   error
+	key   string
+	value interface{}
+}
+
+func (e *detailError) Error() string {
+	return e.err.Error()
+}
+
+func (e *detailError) Unwrap() error {
+	return e.err
+}
+
+// WithDetail annotates err with a value the handler layer adds to the
+// response as an extension member named key
+func WithDetail(err error, key string, value interface{}) error {
+	return &detailError{err: err, key: key, value: value}
+}
+
+// Details collects the values added with WithDetail anywhere in err's chain,
+// or returns nil if there are none. The outermost value wins for a repeated key.
+func Details(err error) map[string]interface{} {
+	var details map[string]interface{}
+	for ; err != nil; err = errors.Unwrap(err) {
+		d, ok := err.(*detailError)
+		if !ok {
+			continue
+		}
+		if details == nil {
+			details = make(map[string]interface{})
+		}
+		if _, seen := details[d.key]; !seen {
+			details[d.key] = d.value
+		}
+	}
+	return details
+}
@@ -2,11 +2,15 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/subosito/gotenv"
+
+	"github.com/naval1525/Social_Media_Backend/internal/ratelimit"
 )
 
 type DatabaseConfig struct {
@@ -19,13 +23,107 @@ type DatabaseConfig struct {
     SSLMode  string `mapstructure:"sslmode"`
 }
 
+// ServerConfig controls the HTTP listener. The timeouts bound how long a client
+// may take to send a request and read the response, so slow clients can't pin
+// connections; ShutdownTimeout is how long in-flight requests get to finish
+// after SIGINT/SIGTERM. ReadinessTimeout bounds each /readyz dependency check.
+// TrustedProxies lists the reverse proxies (CIDRs or IPs) whose X-Forwarded-For
+// header names the client; when empty the direct peer is the client, so behind
+// a load balancer every caller would share its IP and rate limits.
 type ServerConfig struct {
-    Port string `mapstructure:"port"`
+    Port              string        `mapstructure:"port"`
+    ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+    ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+    WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+    IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+    ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`
+    ReadinessTimeout  time.Duration `mapstructure:"readiness_timeout"`
+    TrustedProxies    []string      `mapstructure:"trusted_proxies"`
+}
+
+// AuthConfig controls token lifetimes and signing. Durations use Go syntax, e.g. "15m" or "720h".
+// When KeysDir is set, JWTs are signed with the <ActiveKeyID>.pem key found there;
+// otherwise they fall back to HS256 with JWTSecret. The Login* fields tune
+// brute-force protection on login.
+type AuthConfig struct {
+    Issuer                string        `mapstructure:"issuer"`
+    Audience              string        `mapstructure:"audience"`
+    KeysDir               string        `mapstructure:"keys_dir"`
+    ActiveKeyID           string        `mapstructure:"active_key_id"`
+    AccessTokenTTL        time.Duration `mapstructure:"access_token_ttl"`
+    RefreshTokenTTL       time.Duration `mapstructure:"refresh_token_ttl"`
+    VerificationTokenTTL  time.Duration `mapstructure:"verification_token_ttl"`
+    PasswordResetTokenTTL time.Duration `mapstructure:"password_reset_token_ttl"`
+    LoginMaxAttempts      int           `mapstructure:"login_max_attempts"`
+    LoginLockoutDuration  time.Duration `mapstructure:"login_lockout_duration"`
+    LoginBackoffBase      time.Duration `mapstructure:"login_backoff_base"`
+    LoginIPMaxAttempts    int           `mapstructure:"login_ip_max_attempts"`
+    LoginIPWindow         time.Duration `mapstructure:"login_ip_window"`
+}
+
+// AppConfig describes the deployment and the client app that emails link back to.
+// Env is "production" or "development". Users wait UsernameChangeCooldown between
+// username changes, and an old username stays held for UsernameHoldPeriod. A
+// deleted account can be restored by logging in for AccountDeletionGracePeriod.
+type AppConfig struct {
+    Env                        string        `mapstructure:"env"`
+    BaseURL                    string        `mapstructure:"base_url"`
+    RequireVerifiedEmail       bool          `mapstructure:"require_verified_email"`
+    UsernameChangeCooldown     time.Duration `mapstructure:"username_change_cooldown"`
+    UsernameHoldPeriod         time.Duration `mapstructure:"username_hold_period"`
+    AccountDeletionGracePeriod time.Duration `mapstructure:"account_deletion_grace_period"`
+}
+
+// MailConfig selects and configures the mailer. Driver is "smtp" or "log";
+// the log driver prints messages and, if Dir is set, writes them there as .eml files.
+type MailConfig struct {
+    Driver   string `mapstructure:"driver"`
+    From     string `mapstructure:"from"`
+    Host     string `mapstructure:"host"`
+    Port     string `mapstructure:"port"`
+    Username string `mapstructure:"username"`
+    Password string `mapstructure:"password"`
+    Dir      string `mapstructure:"dir"`
+}
+
+// LogConfig controls structured logging. Format is "json" or "text" and
+// defaults to json in production; Level is debug, info, warn or error.
+type LogConfig struct {
+    Format string `mapstructure:"format"`
+    Level  string `mapstructure:"level"`
+}
+
+// TracingConfig controls OpenTelemetry tracing. Exporter is "otlp", "stdout"
+// (which prints to stderr, away from the logs) or "none"; OTLPEndpoint is a
+// host:port and, when empty, the standard OTEL_EXPORTER_OTLP_* variables
+// apply. SampleRatio is between 0 and 1.
+type TracingConfig struct {
+    Exporter     string  `mapstructure:"exporter"`
+    OTLPEndpoint string  `mapstructure:"otlp_endpoint"`
+    OTLPInsecure bool    `mapstructure:"otlp_insecure"`
+    ServiceName  string  `mapstructure:"service_name"`
+    SampleRatio  float64 `mapstructure:"sample_ratio"`
+}
+
+// RateLimitConfig sets the token-bucket policy for each route group, written
+// as "<limit>/<window>" (e.g. "10/1m"): Auth covers the /auth endpoints, Write
+// authenticated routes and Read public ones.
+type RateLimitConfig struct {
+    Enabled bool   `mapstructure:"enabled"`
+    Auth    string `mapstructure:"auth"`
+    Write   string `mapstructure:"write"`
+    Read    string `mapstructure:"read"`
 }
 
 type Config struct {
     Server   ServerConfig   `mapstructure:"server"`
     JWTSecret string        `mapstructure:"jwt_secret"`
+    Auth     AuthConfig     `mapstructure:"auth"`
+    App      AppConfig      `mapstructure:"app"`
+    Mail     MailConfig     `mapstructure:"mail"`
+    Log      LogConfig      `mapstructure:"log"`
+    Tracing  TracingConfig  `mapstructure:"tracing"`
+    RateLimit RateLimitConfig `mapstructure:"rate_limit"`
     Database DatabaseConfig `mapstructure:"database"`
 }
 
@@ -50,6 +148,88 @@ func Load() (*Config, error) {
     // Explicit env bindings for keys used during Unmarshal
     _ = v.BindEnv("jwt_secret", "JWT_SECRET")
     _ = v.BindEnv("server.port", "SERVER_PORT")
+    _ = v.BindEnv("server.read_timeout", "SERVER_READ_TIMEOUT")
+    _ = v.BindEnv("server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT")
+    _ = v.BindEnv("server.write_timeout", "SERVER_WRITE_TIMEOUT")
+    _ = v.BindEnv("server.idle_timeout", "SERVER_IDLE_TIMEOUT")
+    _ = v.BindEnv("server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT")
+    _ = v.BindEnv("server.readiness_timeout", "SERVER_READINESS_TIMEOUT")
+    _ = v.BindEnv("server.trusted_proxies", "TRUSTED_PROXIES")
+    v.SetDefault("server.read_timeout", "15s")
+    v.SetDefault("server.read_header_timeout", "5s")
+    v.SetDefault("server.write_timeout", "30s")
+    v.SetDefault("server.idle_timeout", "120s")
+    v.SetDefault("server.shutdown_timeout", "30s")
+    v.SetDefault("server.readiness_timeout", "2s")
+    _ = v.BindEnv("auth.issuer", "JWT_ISSUER")
+    _ = v.BindEnv("auth.audience", "JWT_AUDIENCE")
+    _ = v.BindEnv("auth.keys_dir", "JWT_KEYS_DIR")
+    _ = v.BindEnv("auth.active_key_id", "JWT_ACTIVE_KID")
+    v.SetDefault("auth.issuer", "social-media-backend")
+    v.SetDefault("auth.audience", "social-media-api")
+    _ = v.BindEnv("auth.access_token_ttl", "ACCESS_TOKEN_TTL")
+    _ = v.BindEnv("auth.refresh_token_ttl", "REFRESH_TOKEN_TTL")
+    _ = v.BindEnv("auth.verification_token_ttl", "VERIFICATION_TOKEN_TTL")
+    _ = v.BindEnv("auth.password_reset_token_ttl", "PASSWORD_RESET_TOKEN_TTL")
+    // Token lifetimes are tunables rather than secrets, so they get defaults
+    v.SetDefault("auth.access_token_ttl", "15m")
+    v.SetDefault("auth.refresh_token_ttl", "720h")
+    v.SetDefault("auth.verification_token_ttl", "24h")
+    v.SetDefault("auth.password_reset_token_ttl", "1h")
+
+    _ = v.BindEnv("auth.login_max_attempts", "LOGIN_MAX_ATTEMPTS")
+    _ = v.BindEnv("auth.login_lockout_duration", "LOGIN_LOCKOUT_DURATION")
+    _ = v.BindEnv("auth.login_backoff_base", "LOGIN_BACKOFF_BASE")
+    _ = v.BindEnv("auth.login_ip_max_attempts", "LOGIN_IP_MAX_ATTEMPTS")
+    _ = v.BindEnv("auth.login_ip_window", "LOGIN_IP_WINDOW")
+    v.SetDefault("auth.login_max_attempts", 5)
+    v.SetDefault("auth.login_lockout_duration", "15m")
+    v.SetDefault("auth.login_backoff_base", "1s")
+    v.SetDefault("auth.login_ip_max_attempts", 20)
+    v.SetDefault("auth.login_ip_window", "15m")
+
+    _ = v.BindEnv("app.env", "APP_ENV")
+    v.SetDefault("app.env", "development")
+    _ = v.BindEnv("app.base_url", "APP_BASE_URL")
+    _ = v.BindEnv("app.require_verified_email", "REQUIRE_VERIFIED_EMAIL")
+    _ = v.BindEnv("app.username_change_cooldown", "USERNAME_CHANGE_COOLDOWN")
+    _ = v.BindEnv("app.username_hold_period", "USERNAME_HOLD_PERIOD")
+    v.SetDefault("app.username_change_cooldown", "720h")
+    v.SetDefault("app.username_hold_period", "720h")
+    _ = v.BindEnv("app.account_deletion_grace_period", "ACCOUNT_DELETION_GRACE_PERIOD")
+    v.SetDefault("app.account_deletion_grace_period", "720h")
+
+    _ = v.BindEnv("mail.driver", "MAIL_DRIVER")
+    _ = v.BindEnv("mail.from", "MAIL_FROM")
+    _ = v.BindEnv("mail.host", "SMTP_HOST")
+    _ = v.BindEnv("mail.port", "SMTP_PORT")
+    _ = v.BindEnv("mail.username", "SMTP_USERNAME")
+    _ = v.BindEnv("mail.password", "SMTP_PASSWORD")
+    _ = v.BindEnv("mail.dir", "MAIL_DIR")
+    v.SetDefault("mail.driver", "log")
+    v.SetDefault("mail.port", "587")
+
+    _ = v.BindEnv("log.format", "LOG_FORMAT")
+    _ = v.BindEnv("log.level", "LOG_LEVEL")
+    v.SetDefault("log.level", "info")
+
+    _ = v.BindEnv("rate_limit.enabled", "RATE_LIMIT_ENABLED")
+    _ = v.BindEnv("rate_limit.auth", "RATE_LIMIT_AUTH")
+    _ = v.BindEnv("rate_limit.write", "RATE_LIMIT_WRITE")
+    _ = v.BindEnv("rate_limit.read", "RATE_LIMIT_READ")
+    v.SetDefault("rate_limit.enabled", true)
+    v.SetDefault("rate_limit.auth", "10/1m")
+    v.SetDefault("rate_limit.write", "60/1m")
+    v.SetDefault("rate_limit.read", "300/1m")
+
+    _ = v.BindEnv("tracing.exporter", "TRACING_EXPORTER")
+    _ = v.BindEnv("tracing.otlp_endpoint", "TRACING_OTLP_ENDPOINT")
+    _ = v.BindEnv("tracing.otlp_insecure", "TRACING_OTLP_INSECURE")
+    _ = v.BindEnv("tracing.service_name", "OTEL_SERVICE_NAME")
+    _ = v.BindEnv("tracing.sample_ratio", "TRACING_SAMPLE_RATIO")
+    v.SetDefault("tracing.exporter", "none")
+    v.SetDefault("tracing.service_name", "social-media-backend")
+    v.SetDefault("tracing.sample_ratio", 1.0)
     // Common alias for PaaS
     if v.GetString("server.port") == "" {
         if p := os.Getenv("PORT"); p != "" {
@@ -83,6 +263,12 @@ func Load() (*Config, error) {
     if err := v.Unmarshal(&cfg); err != nil {
         return nil, err
     }
+    if cfg.Log.Format == "" {
+        cfg.Log.Format = "text"
+        if cfg.App.Env == "production" {
+            cfg.Log.Format = "json"
+        }
+    }
     if err := cfg.Validate(); err != nil {
         return nil, err
     }
@@ -91,12 +277,73 @@ func Load() (*Config, error) {
 
 // Validate ensures required config values are present (no in-code fallbacks)
 func (c *Config) Validate() error {
-    if c.JWTSecret == "" {
-        return fmt.Errorf("JWT secret is required (env JWT_SECRET)")
+    if c.Auth.KeysDir == "" && c.JWTSecret == "" {
+        return fmt.Errorf("JWT secret is required (env JWT_SECRET) unless signing keys are configured (env JWT_KEYS_DIR)")
+    }
+    if c.Auth.KeysDir != "" && c.Auth.ActiveKeyID == "" {
+        return fmt.Errorf("active signing key is required when JWT_KEYS_DIR is set (env JWT_ACTIVE_KID)")
+    }
+    if c.Auth.Issuer == "" || c.Auth.Audience == "" {
+        return fmt.Errorf("token issuer and audience are required (env JWT_ISSUER, JWT_AUDIENCE)")
+    }
+    if c.Auth.AccessTokenTTL <= 0 || c.Auth.RefreshTokenTTL <= 0 ||
+        c.Auth.VerificationTokenTTL <= 0 || c.Auth.PasswordResetTokenTTL <= 0 {
+        return fmt.Errorf("token lifetimes must be positive (env ACCESS_TOKEN_TTL, REFRESH_TOKEN_TTL, VERIFICATION_TOKEN_TTL, PASSWORD_RESET_TOKEN_TTL)")
+    }
+    if c.Auth.LoginMaxAttempts <= 0 || c.Auth.LoginIPMaxAttempts <= 0 ||
+        c.Auth.LoginLockoutDuration <= 0 || c.Auth.LoginBackoffBase <= 0 || c.Auth.LoginIPWindow <= 0 {
+        return fmt.Errorf("login protection settings must be positive (env LOGIN_MAX_ATTEMPTS, LOGIN_LOCKOUT_DURATION, LOGIN_BACKOFF_BASE, LOGIN_IP_MAX_ATTEMPTS, LOGIN_IP_WINDOW)")
+    }
+    if c.App.Env != "production" && c.App.Env != "development" {
+        return fmt.Errorf("unknown app environment %q (env APP_ENV: production or development)", c.App.Env)
+    }
+    if c.App.UsernameChangeCooldown < 0 || c.App.UsernameHoldPeriod < 0 {
+        return fmt.Errorf("username change settings must not be negative (env USERNAME_CHANGE_COOLDOWN, USERNAME_HOLD_PERIOD)")
+    }
+    if c.App.AccountDeletionGracePeriod <= 0 {
+        return fmt.Errorf("account deletion grace period must be positive (env ACCOUNT_DELETION_GRACE_PERIOD)")
+    }
+    if c.Log.Format != "json" && c.Log.Format != "text" {
+        return fmt.Errorf("unknown log format %q (env LOG_FORMAT: json or text)", c.Log.Format)
+    }
+    switch c.Tracing.Exporter {
+    case "none", "stdout", "otlp":
+    default:
+        return fmt.Errorf("unknown trace exporter %q (env TRACING_EXPORTER: otlp, stdout or none)", c.Tracing.Exporter)
+    }
+    if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+        return fmt.Errorf("trace sample ratio must be between 0 and 1 (env TRACING_SAMPLE_RATIO)")
+    }
+    for name, spec := range map[string]string{"RATE_LIMIT_AUTH": c.RateLimit.Auth, "RATE_LIMIT_WRITE": c.RateLimit.Write, "RATE_LIMIT_READ": c.RateLimit.Read} {
+        if _, err := ratelimit.ParsePolicy(name, spec); err != nil {
+            return err
+        }
+    }
+    switch c.Mail.Driver {
+    case "log":
+    case "smtp":
+        if c.Mail.Host == "" || c.Mail.From == "" {
+            return fmt.Errorf("smtp mail driver requires SMTP_HOST and MAIL_FROM")
+        }
+    default:
+        return fmt.Errorf("unknown mail driver %q (env MAIL_DRIVER: smtp or log)", c.Mail.Driver)
     }
     if c.Server.Port == "" {
         return fmt.Errorf("server port is required (env SERVER_PORT or PORT)")
     }
+    if c.Server.ReadTimeout <= 0 || c.Server.ReadHeaderTimeout <= 0 || c.Server.WriteTimeout <= 0 ||
+        c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 || c.Server.ReadinessTimeout <= 0 {
+        return fmt.Errorf("server timeouts must be positive (env SERVER_READ_TIMEOUT, SERVER_READ_HEADER_TIMEOUT, SERVER_WRITE_TIMEOUT, SERVER_IDLE_TIMEOUT, SERVER_SHUTDOWN_TIMEOUT, SERVER_READINESS_TIMEOUT)")
+    }
+    for _, proxy := range c.Server.TrustedProxies {
+        proxy = strings.TrimSpace(proxy)
+        if proxy == "" || net.ParseIP(proxy) != nil {
+            continue
+        }
+        if _, _, err := net.ParseCIDR(proxy); err != nil {
+            return fmt.Errorf("invalid trusted proxy %q (env TRUSTED_PROXIES: comma-separated CIDRs or IPs)", proxy)
+        }
+    }
     if c.Database.URL == "" {
         // Require discrete fields
         if c.Database.Host == "" || c.Database.Port == "" || c.Database.User == "" || c.Database.Name == "" {
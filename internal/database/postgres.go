@@ -1,21 +1,34 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	migrate "github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
 )
 
+// migrationsDir holds the *.up.sql/*.down.sql files, relative to the working directory
+const migrationsDir = "migrations"
+
+// driverName is lib/pq wrapped so that queries show up as spans in request traces
+const driverName = "postgres+traced"
+
+func init() {
+	sql.Register(driverName, tracing.WrapDriver(&pq.Driver{}))
+}
+
 type ConnParams struct {
     URL      string
     Host     string
@@ -34,7 +47,7 @@ type DB struct {
 func NewPostgresConnection() (*DB, error) {
     // Preserve existing behavior if called without explicit params by reading envs
     if url := os.Getenv("DB_URL"); url != "" {
-        db, err := sql.Open("postgres", url)
+        db, err := sql.Open(driverName, url)
         if err != nil {
             return nil, fmt.Errorf("failed to open db with DB_URL: %w", err)
         }
@@ -44,7 +57,7 @@ func NewPostgresConnection() (*DB, error) {
         if err = db.Ping(); err != nil {
             return nil, fmt.Errorf("failed to ping db: %w", err)
         }
-        log.Println("✅ Successfully connected to PostgreSQL database")
+        slog.Info("connected to PostgreSQL database")
         return &DB{db}, nil
     }
 
@@ -57,7 +70,7 @@ func NewPostgresConnection() (*DB, error) {
 
     connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, name)
 
-	db, err := sql.Open("postgres", connStr)
+	db, err := sql.Open(driverName, connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open db: %w", err)
 	}
@@ -68,7 +81,7 @@ func NewPostgresConnection() (*DB, error) {
 		return nil, fmt.Errorf("failed to ping db: %w", err)
 	}
 
-	log.Println("✅ Successfully connected to PostgreSQL database")
+	slog.Info("connected to PostgreSQL database")
 
 	return &DB{db}, nil
 
@@ -78,7 +91,7 @@ func NewPostgresConnection() (*DB, error) {
 func (db *DB) Migrate() error {
     // Best-effort preflight to ensure essential schema exists for existing DBs
     if err := db.preflightEnsureUsersSchema(); err != nil {
-        log.Printf("warning: users schema preflight failed: %v", err)
+        slog.Warn("users schema preflight failed", "error", err)
     }
 
     // Use golang-migrate with file:// source from project root
@@ -87,14 +100,14 @@ func (db *DB) Migrate() error {
         return fmt.Errorf("failed to init migration driver: %w", err)
     }
 
-    m, err := migrate.NewWithDatabaseInstance("file://migrations", "postgres", driver)
+    m, err := migrate.NewWithDatabaseInstance("file://"+migrationsDir, "postgres", driver)
     if err != nil {
         return fmt.Errorf("failed to create migrator: %w", err)
     }
 
     // Handle version mismatch and dirty states proactively
     if v, dirty, verr := m.Version(); verr == nil {
-        maxFileV, maxErr := getMaxMigrationVersion("migrations")
+        maxFileV, maxErr := getMaxMigrationVersion(migrationsDir)
         if maxErr == nil {
             if int(v) > maxFileV {
                 if ferr := m.Force(maxFileV); ferr != nil {
@@ -112,16 +125,45 @@ func (db *DB) Migrate() error {
     if err := m.Up(); err != nil && err != migrate.ErrNoChange {
         // If lock/prepared statement issues likely due to pooler, skip hard failure
         if isPoolerLockError(err) {
-            log.Printf("warning: skipping migrations due to pooler lock error: %v", err)
+            slog.Warn("skipping migrations due to pooler lock error", "error", err)
         } else {
             return fmt.Errorf("migration failed: %w", err)
         }
     }
 
-    log.Println("✅ Database migrations up-to-date")
+    slog.Info("database migrations up to date")
     return nil
 }
 
+// MigrationStatus describes the schema version recorded by golang-migrate
+type MigrationStatus struct {
+    // Version is the last migration applied; Dirty means it failed partway
+    Version int
+    Dirty   bool
+    // Latest is the newest migration shipped with this build
+    Latest int
+}
+
+// MigrationStatus reads the applied schema version and compares it with the
+// migration files on disk
+func (db *DB) MigrationStatus(ctx context.Context) (*MigrationStatus, error) {
+    status := &MigrationStatus{}
+    err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&status.Version, &status.Dirty)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, fmt.Errorf("no migrations have been applied")
+        }
+        return nil, fmt.Errorf("failed to read migration version: %w", err)
+    }
+
+    status.Latest, err = getMaxMigrationVersion(migrationsDir)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read migration files: %w", err)
+    }
+
+    return status, nil
+}
+
 // isPoolerLockError detects errors commonly seen when running golang-migrate through PgBouncer/Neon pooler
 func isPoolerLockError(err error) bool {
     if err == nil {
@@ -228,7 +270,7 @@ func getenvDefault(key, fallback string) string {
 // NewWithParams allows passing explicit connection params (from config) instead of env
 func NewWithParams(p ConnParams) (*DB, error) {
     if p.URL != "" {
-        db, err := sql.Open("postgres", p.URL)
+        db, err := sql.Open(driverName, p.URL)
         if err != nil {
             return nil, fmt.Errorf("failed to open db with URL: %w", err)
         }
@@ -238,7 +280,7 @@ func NewWithParams(p ConnParams) (*DB, error) {
         if err = db.Ping(); err != nil {
             return nil, fmt.Errorf("failed to ping db: %w", err)
         }
-        log.Println("✅ Successfully connected to PostgreSQL database")
+        slog.Info("connected to PostgreSQL database")
         return &DB{db}, nil
     }
 
@@ -248,7 +290,7 @@ func NewWithParams(p ConnParams) (*DB, error) {
     name := firstNonEmpty(p.Name, "social_media")
     ssl := firstNonEmpty(p.SSLMode, "disable")
     connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", host, port, user, p.Password, name, ssl)
-    db, err := sql.Open("postgres", connStr)
+    db, err := sql.Open(driverName, connStr)
     if err != nil {
         return nil, fmt.Errorf("failed to open db: %w", err)
     }
@@ -258,7 +300,7 @@ func NewWithParams(p ConnParams) (*DB, error) {
     if err = db.Ping(); err != nil {
         return nil, fmt.Errorf("failed to ping db: %w", err)
     }
-    log.Println("✅ Successfully connected to PostgreSQL database")
+    slog.Info("connected to PostgreSQL database")
     return &DB{db}, nil
 }
 
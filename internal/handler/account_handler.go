@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/naval1525/Social_Media_Backend/internal/service"
+)
+
+type AccountHandler struct {
+	accountService service.AccountService
+}
+
+func NewAccountHandler(accountService service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// VerifyEmail handles redeeming an email verification token
+func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token" validate:"required"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(r.Context(), req.Token); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Email verified successfully", nil)
+}
+
+// ResendVerification handles sending the current user a new verification email
+func (h *AccountHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.accountService.SendVerificationEmail(r.Context(), userID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Verification email sent", nil)
+}
+
+// ForgotPassword handles requesting a password reset email
+func (h *AccountHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(r.Context(), req.Email); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Same response whether or not the email is registered
+	writeSuccessResponse(w, http.StatusOK, "If that email is registered, a reset link has been sent", nil)
+}
+
+// ResetPassword handles setting a new password with a reset token
+func (h *AccountHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required,min=6,max=72,maxbytes=72"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.accountService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Password reset successfully", nil)
+}
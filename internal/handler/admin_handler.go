@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/service"
+)
+
+type AdminHandler struct {
+	adminService service.AdminService
+}
+
+func NewAdminHandler(adminService service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// ListUsers handles listing every user account
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	users, next, err := h.adminService.ListUsers(r.Context(), page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writePageResponse(w, "Users retrieved successfully", users, next)
+}
+
+// suspendRequest is the body accepted by the suspend endpoint
+type suspendRequest struct {
+	Reason string    `json:"reason" validate:"required,max=500"`
+	Until  time.Time `json:"until" validate:"required"`
+}
+
+// banRequest is the body accepted by the ban endpoint
+type banRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// moderationRequest is the body accepted by the reversal endpoints, where
+// the reason is optional
+type moderationRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// SuspendUser handles suspending a user account until a given time
+func (h *AdminHandler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	actor, userID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req suspendRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.adminService.SuspendUser(r.Context(), actor, userID, req.Until, req.Reason); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "User suspended successfully", nil)
+}
+
+// UnsuspendUser handles lifting a user's suspension early
+func (h *AdminHandler) UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	actor, userID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := decodeOptionalModerationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.UnsuspendUser(r.Context(), actor, userID, req.Reason); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "User unsuspended successfully", nil)
+}
+
+// BanUser handles permanently banning a user account
+func (h *AdminHandler) BanUser(w http.ResponseWriter, r *http.Request) {
+	actor, userID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req banRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.adminService.BanUser(r.Context(), actor, userID, req.Reason); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "User banned successfully", nil)
+}
+
+// UnbanUser handles lifting a user's ban
+func (h *AdminHandler) UnbanUser(w http.ResponseWriter, r *http.Request) {
+	actor, userID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := decodeOptionalModerationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.UnbanUser(r.Context(), actor, userID, req.Reason); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "User unbanned successfully", nil)
+}
+
+// GetModerationActions handles listing the suspensions and bans applied to a user
+func (h *AdminHandler) GetModerationActions(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	actions, next, err := h.adminService.ListModerationActions(r.Context(), userID, page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writePageResponse(w, "Moderation actions retrieved successfully", actions, next)
+}
+
+// DeleteUser handles deleting a user account
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	actor, userID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.DeleteUser(r.Context(), actor, userID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "User deleted successfully", nil)
+}
+
+// ChangeRole handles changing a user's role
+func (h *AdminHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	actor, userID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role model.Role `json:"role" validate:"required,oneof=user moderator admin"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.adminService.ChangeRole(r.Context(), actor, userID, req.Role)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Role updated successfully", user)
+}
+
+// adminTarget reads the acting staff member and the user ID in the path,
+// writing an error response and returning false if either is missing
+func adminTarget(w http.ResponseWriter, r *http.Request) (*model.Principal, uuid.UUID, bool) {
+	actor, err := getPrincipalFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return nil, uuid.Nil, false
+	}
+
+	return actor, userID, true
+}
+
+// decodeOptionalModerationRequest reads a reversal's body, where a reason is
+// optional and the body may be omitted entirely
+func decodeOptionalModerationRequest(w http.ResponseWriter, r *http.Request) (moderationRequest, bool) {
+	var req moderationRequest
+	ok := decodeOptionalJSON(w, r, &req)
+	return req, ok
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/naval1525/Social_Media_Backend/internal/service"
+)
+
+type AuthHandler struct {
+	authService service.AuthService
+}
+
+func NewAuthHandler(authService service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// refreshTokenRequest is the body accepted by refresh and logout
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// Refresh handles exchanging a refresh token for a new token pair
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.authService.Refresh(r.Context(), req.RefreshToken, clientInfoFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Token refreshed successfully", tokens)
+}
+
+// Logout handles revoking the session a refresh token belongs to
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.authService.Logout(r.Context(), req.RefreshToken); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Logged out successfully", nil)
+}
+
+// LogoutAll handles revoking every session of the current user
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.authService.LogoutAll(r.Context(), userID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Logged out of all sessions successfully", nil)
+}
+
+// JWKS serves the public signing keys as a bare JSON Web Key Set, the format
+// JWT libraries expect, so other services can verify our tokens
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.authService.JWKS())
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/service"
+)
+
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// CreateComment handles commenting on a post
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	postID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	var req model.CommentRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(r.Context(), userID, postID, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Comment created successfully", comment)
+}
+
+// GetComments handles listing a post's comments
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	comments, next, err := h.commentService.GetComments(r.Context(), postID, page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writePageResponse(w, "Comments retrieved successfully", comments, next)
+}
+
+// DeleteComment handles deleting a comment
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	commentID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	if err := h.commentService.DeleteComment(r.Context(), userID, commentID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Comment deleted successfully", nil)
+}
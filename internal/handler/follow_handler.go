@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/naval1525/Social_Media_Backend/internal/service"
+)
+
+type FollowHandler struct {
+	followService service.FollowService
+}
+
+func NewFollowHandler(followService service.FollowService) *FollowHandler {
+	return &FollowHandler{
+		followService: followService,
+	}
+}
+
+// Follow handles following another user
+func (h *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	followerID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	followedID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.followService.Follow(r.Context(), followerID, followedID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "User followed successfully", nil)
+}
+
+// Unfollow handles unfollowing another user
+func (h *FollowHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	followerID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	followedID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.followService.Unfollow(r.Context(), followerID, followedID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "User unfollowed successfully", nil)
+}
+
+// GetFollowers handles listing the users who follow a user
+func (h *FollowHandler) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	users, next, err := h.followService.GetFollowers(r.Context(), userID, page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writePageResponse(w, "Followers retrieved successfully", users, next)
+}
+
+// GetFollowing handles listing the users a user follows
+func (h *FollowHandler) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	users, next, err := h.followService.GetFollowing(r.Context(), userID, page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writePageResponse(w, "Following retrieved successfully", users, next)
+}
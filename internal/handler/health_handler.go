@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/naval1525/Social_Media_Backend/internal/health"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Livez reports that the process is up and serving. It checks no
+// dependencies, so an outage elsewhere never gets the instance restarted.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	writeHealthResponse(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// Readyz runs the registered dependency checks and answers 503 if any fail,
+// so load balancers stop routing traffic here until they recover
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealthResponse(w, status, report)
+}
+
+// writeHealthResponse writes a probe result. Probes must always see the
+// current state, so responses are never cached.
+func writeHealthResponse(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
@@ -3,19 +3,40 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/logging"
+	"github.com/naval1525/Social_Media_Backend/internal/metrics"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/ratelimit"
 	"github.com/naval1525/Social_Media_Backend/internal/service"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-}
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
 
 // SuccessResponse represents a success response
 type SuccessResponse struct {
@@ -23,8 +44,17 @@ type SuccessResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware(userService service.UserService) func(http.Handler) http.Handler {
+// PageResponse represents a success response for a paginated list.
+// Pass NextCursor back as ?cursor= to fetch the following page.
+type PageResponse struct {
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// AuthMiddleware validates JWT access tokens and rejects revoked sessions
+func AuthMiddleware(authService service.AuthService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get token from Authorization header
@@ -44,21 +74,25 @@ func AuthMiddleware(userService service.UserService) func(http.Handler) http.Han
 			token := parts[1]
 
 			// Validate token
-			userID, err := userService.ValidateJWT(token)
+			principal, err := authService.ValidateAccessToken(r.Context(), token)
 			if err != nil {
-				writeErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+				// Suspended and banned accounts get a 403 with their own code
+				if errors.Is(err, apperr.ErrForbidden) {
+					writeError(w, err)
+				} else {
+					writeErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+				}
 				return
 			}
 
-			// Add user ID to request context
-			ctx := context.WithValue(r.Context(), "user_id", userID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			// Add user ID and role to request context
+			next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), principal)))
 		})
 	}
 }
 
 // OptionalAuthMiddleware validates JWT tokens but doesn't require them
-func OptionalAuthMiddleware(userService service.UserService) func(http.Handler) http.Handler {
+func OptionalAuthMiddleware(authService service.AuthService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -66,9 +100,8 @@ func OptionalAuthMiddleware(userService service.UserService) func(http.Handler)
 				parts := strings.Split(authHeader, " ")
 				if len(parts) == 2 && parts[0] == "Bearer" {
 					token := parts[1]
-					if userID, err := userService.ValidateJWT(token); err == nil {
-						ctx := context.WithValue(r.Context(), "user_id", userID)
-						r = r.WithContext(ctx)
+					if principal, err := authService.ValidateAccessToken(r.Context(), token); err == nil {
+						r = r.WithContext(withPrincipal(r.Context(), principal))
 					}
 				}
 			}
@@ -77,12 +110,72 @@ func OptionalAuthMiddleware(userService service.UserService) func(http.Handler)
 	}
 }
 
+// RequirePermission rejects callers whose role doesn't grant permission.
+// It must run after AuthMiddleware.
+func RequirePermission(permission model.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := getPrincipalFromContext(r.Context())
+			if err != nil {
+				writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			if !principal.Role.Can(permission) {
+				writeErrorResponse(w, http.StatusForbidden, "You do not have permission to perform this action")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitMiddleware counts each request against policy, keyed on the
+// caller's user ID when authenticated and on client IP otherwise. It must run
+// after the route's auth middleware for user keys to apply. Every response
+// carries RateLimit-* headers; rejected ones get 429 and Retry-After. If the
+// store fails, requests are let through rather than taking the API down.
+func RateLimitMiddleware(store ratelimit.Store, policy ratelimit.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := policy.Name + ":ip:" + clientInfoFromRequest(r).IPAddress
+			if userID, err := getUserIDFromContext(r.Context()); err == nil {
+				key = policy.Name + ":user:" + userID.String()
+			}
+
+			result, err := store.Take(r.Context(), key, policy)
+			if err != nil {
+				slog.WarnContext(r.Context(), "rate limit store failed, allowing request", "policy", policy.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", ceilSeconds(result.ResetAfter))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", policy.Limit, ceilSeconds(policy.Window)))
+
+			if !result.Allowed {
+				metrics.RateLimited(policy.Name)
+				header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+				writeErrorCode(w, http.StatusTooManyRequests, codeRateLimited, "Too many requests, please try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // CORSMiddleware handles CORS headers
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, X-Request-ID, traceparent, tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, X-Request-ID, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -93,14 +186,189 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// LoggingMiddleware logs HTTP requests
+// RequestIDMiddleware propagates the caller's X-Request-ID, or generates one,
+// echoes it on the response and stores it in the request context for logging
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// LoggingMiddleware logs one line per request once it completes and records
+// its HTTP metrics. Requests that ended in a server error are logged at error
+// level along with the cause.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// You can implement proper logging here
-		next.ServeHTTP(w, r)
+		start := time.Now()
+		rec := &requestLog{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), "request_log", rec)))
+
+		latency := time.Since(start)
+		route := routeTemplate(r)
+		metrics.ObserveHTTPRequest(r.Method, route, rec.status, latency)
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("latency", latency),
+			slog.Int("bytes", rec.bytes),
+		}
+		if rec.userID != uuid.Nil {
+			attrs = append(attrs, slog.String("user_id", rec.userID.String()))
+		}
+		if rec.traceID != "" {
+			attrs = append(attrs, slog.String("trace_id", rec.traceID))
+		}
+
+		level := slog.LevelInfo
+		if rec.err != nil {
+			attrs = append(attrs, slog.String("error", rec.err.Error()))
+		}
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.LogAttrs(r.Context(), level, "request", attrs...)
 	})
 }
 
+// TracingMiddleware continues the caller's trace, or starts a new one, with a
+// server span covering the request. Spans are named after the route template
+// so that calls to the same endpoint group together. It must run inside
+// LoggingMiddleware, whose log line it tags with the trace ID.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := routeTemplate(r)
+		name := r.Method
+		if route != "" {
+			name += " " + route
+		}
+
+		ctx, span := tracing.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+				semconv.ClientAddress(clientInfoFromRequest(r).IPAddress),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		rec, _ := w.(*requestLog)
+		if rec != nil && span.SpanContext().IsValid() {
+			rec.traceID = span.SpanContext().TraceID().String()
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+		if rec == nil {
+			return
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.userID != uuid.Nil {
+			span.SetAttributes(attribute.String("enduser.id", rec.userID.String()))
+		}
+		if rec.status >= http.StatusInternalServerError {
+			if rec.err != nil {
+				tracing.RecordError(span, rec.err)
+			} else {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
+		}
+	})
+}
+
+// NotFoundHandler answers requests that match no route
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeErrorResponse(w, http.StatusNotFound, "Route not found")
+	})
+}
+
+// MethodNotAllowedHandler answers requests whose path matches but method doesn't
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	})
+}
+
+// requestLog records what LoggingMiddleware reports about a request. Handlers
+// further down reach it through the response writer or the request context.
+type requestLog struct {
+	http.ResponseWriter
+	status  int
+	bytes   int
+	userID  uuid.UUID
+	traceID string
+	err     error
+}
+
+func (l *requestLog) WriteHeader(status int) {
+	l.status = status
+	l.ResponseWriter.WriteHeader(status)
+}
+
+func (l *requestLog) Write(b []byte) (int, error) {
+	n, err := l.ResponseWriter.Write(b)
+	l.bytes += n
+	return n, err
+}
+
+// routeTemplate returns the path template of the route r matched, or "" if none did
+func routeTemplate(r *http.Request) string {
+	current := mux.CurrentRoute(r)
+	if current == nil {
+		return ""
+	}
+	route, _ := current.GetPathTemplate()
+	return route
+}
+
+// ceilSeconds formats d as whole seconds, rounded up so clients never retry early
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// validRequestID accepts short IDs made of visible ASCII so a caller can't
+// inject anything odd into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// recordError attaches the cause of a failed request to its log line
+func recordError(w http.ResponseWriter, err error) {
+	if rec, ok := w.(*requestLog); ok {
+		rec.err = err
+	}
+}
+
+// writeServerError logs err with the request and writes a generic 500
+func writeServerError(w http.ResponseWriter, err error) {
+	recordError(w, err)
+	writeErrorResponse(w, http.StatusInternalServerError, "Something went wrong")
+}
+
 // getUserIDFromContext extracts user ID from request context
 func getUserIDFromContext(ctx context.Context) (uuid.UUID, error) {
 	userID, ok := ctx.Value("user_id").(uuid.UUID)
@@ -110,14 +378,157 @@ func getUserIDFromContext(ctx context.Context) (uuid.UUID, error) {
 	return userID, nil
 }
 
-// writeErrorResponse writes an error response
+// withPrincipal stores the authenticated caller's ID and role in the context
+// and notes the user on the request's log line
+func withPrincipal(ctx context.Context, principal *model.Principal) context.Context {
+	if rec, ok := ctx.Value("request_log").(*requestLog); ok {
+		rec.userID = principal.UserID
+	}
+	ctx = context.WithValue(ctx, "user_id", principal.UserID)
+	return context.WithValue(ctx, "role", principal.Role)
+}
+
+// getPrincipalFromContext extracts the authenticated caller from request context
+func getPrincipalFromContext(ctx context.Context) (*model.Principal, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	role, ok := ctx.Value("role").(model.Role)
+	if !ok {
+		return nil, fmt.Errorf("role not found in context")
+	}
+
+	return &model.Principal{UserID: userID, Role: role}, nil
+}
+
+// ParseTrustedProxies parses the CIDRs of reverse proxies allowed to report
+// the client's address. A bare IP is taken as a single-address range.
+func ParseTrustedProxies(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", cidr)
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ClientIPMiddleware resolves the caller's IP once for rate limiting, logging
+// and session records. When the direct peer is a trusted proxy, the
+// X-Forwarded-For chain is read from the right, skipping trusted hops, and
+// the first other address is the client. Without trusted proxies the header
+// is ignored, since any client can set it.
+func ClientIPMiddleware(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r, trustedProxies)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "client_ip", ip)))
+		})
+	}
+}
+
+// clientIP picks the client address as described on ClientIPMiddleware
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	if !isTrustedProxy(ip, trustedProxies) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// A malformed entry can't be trusted, nor anything left of it
+			break
+		}
+		ip = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+	return ip
+}
+
+// isTrustedProxy reports whether ip falls in one of the trusted ranges
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientInfoFromRequest describes the calling client for session bookkeeping.
+// The IP comes from ClientIPMiddleware, or the direct peer if it didn't run.
+func clientInfoFromRequest(r *http.Request) model.ClientInfo {
+	ip, ok := r.Context().Value("client_ip").(string)
+	if !ok {
+		ip = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+	}
+
+	return model.ClientInfo{
+		IPAddress: ip,
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// parsePageRequest reads the limit and cursor query parameters with sane bounds
+func parsePageRequest(r *http.Request) (model.PageRequest, error) {
+	page := model.PageRequest{Limit: defaultPageLimit}
+
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		page.Limit = v
+	}
+	if page.Limit > maxPageLimit {
+		page.Limit = maxPageLimit
+	}
+
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		cursor, err := model.DecodeCursor(v)
+		if err != nil {
+			return page, err
+		}
+		page.After = cursor
+	}
+
+	return page, nil
+}
+
+// writeErrorResponse writes an error response whose code is derived from the status
 func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(ErrorResponse{
-		Error:   http.StatusText(statusCode),
-		Message: message,
-	})
+	writeErrorCode(w, statusCode, "", message)
+}
+
+// writeErrorCode writes an error response carrying a machine-readable code
+func writeErrorCode(w http.ResponseWriter, statusCode int, code, message string) {
+	writeProblem(w, Problem{Status: statusCode, Code: code, Detail: message})
 }
 
 // writeSuccessResponse writes a success response
@@ -129,3 +540,19 @@ func writeSuccessResponse(w http.ResponseWriter, statusCode int, message string,
 		Data:    data,
 	})
 }
+
+// writePageResponse writes a paginated list response
+func writePageResponse(w http.ResponseWriter, message string, data interface{}, next *model.Cursor) {
+	response := PageResponse{
+		Message: message,
+		Data:    data,
+	}
+	if next != nil {
+		response.NextCursor = next.Encode()
+		response.HasMore = true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
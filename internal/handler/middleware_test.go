@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *model.Principal
+		permission model.Permission
+		wantStatus int
+	}{
+		{"anonymous", nil, model.PermissionListUsers, http.StatusUnauthorized},
+		{"user", &model.Principal{Role: model.RoleUser}, model.PermissionListUsers, http.StatusForbidden},
+		{"moderator listing", &model.Principal{Role: model.RoleModerator}, model.PermissionListUsers, http.StatusOK},
+		{"moderator suspending", &model.Principal{Role: model.RoleModerator}, model.PermissionSuspendUsers, http.StatusOK},
+		{"moderator banning", &model.Principal{Role: model.RoleModerator}, model.PermissionBanUsers, http.StatusForbidden},
+		{"moderator deleting", &model.Principal{Role: model.RoleModerator}, model.PermissionDeleteUsers, http.StatusForbidden},
+		{"moderator managing roles", &model.Principal{Role: model.RoleModerator}, model.PermissionManageRoles, http.StatusForbidden},
+		{"admin deleting", &model.Principal{Role: model.RoleAdmin}, model.PermissionDeleteUsers, http.StatusOK},
+		{"admin managing roles", &model.Principal{Role: model.RoleAdmin}, model.PermissionManageRoles, http.StatusOK},
+		{"unknown role", &model.Principal{Role: "owner"}, model.PermissionListUsers, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := RequirePermission(tt.permission)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+			if tt.principal != nil {
+				tt.principal.UserID = uuid.New()
+				r = r.WithContext(withPrincipal(r.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if called != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("next called = %v, want %v", called, tt.wantStatus == http.StatusOK)
+			}
+			if tt.wantStatus != http.StatusOK && w.Header().Get("Content-Type") != problemContentType {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), problemContentType)
+			}
+		})
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		proxies    []*net.IPNet
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, trusted, "203.0.113.7"},
+		{"header ignored without trusted proxies", "10.0.0.5:5000", []string{"198.51.100.9"}, nil, "10.0.0.5"},
+		{"header ignored from untrusted peer", "203.0.113.7:5000", []string{"198.51.100.9"}, trusted, "203.0.113.7"},
+		{"single proxy", "10.0.0.5:5000", []string{"198.51.100.9"}, trusted, "198.51.100.9"},
+		{"spoofed entries left of the client", "10.0.0.5:5000", []string{"1.1.1.1, 198.51.100.9"}, trusted, "198.51.100.9"},
+		{"chain of trusted proxies", "10.0.0.5:5000", []string{"198.51.100.9, 192.0.2.1", "10.1.2.3"}, trusted, "198.51.100.9"},
+		{"malformed hop", "10.0.0.5:5000", []string{"198.51.100.9, garbage"}, trusted, "10.0.0.5"},
+		{"only trusted hops", "10.0.0.5:5000", []string{"10.9.9.9"}, trusted, "10.9.9.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := ClientIPMiddleware(tt.proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientInfoFromRequest(r).IPAddress
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsGarbage(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+	if _, err := ParseTrustedProxies([]string{"proxy.internal"}); err == nil {
+		t.Error("expected an error for a hostname")
+	}
+}
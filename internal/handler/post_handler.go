@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/service"
+)
+
+type PostHandler struct {
+	postService service.PostService
+}
+
+func NewPostHandler(postService service.PostService) *PostHandler {
+	return &PostHandler{
+		postService: postService,
+	}
+}
+
+// CreatePost handles publishing a new post
+func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req model.PostRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	post, err := h.postService.CreatePost(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Post created successfully", post)
+}
+
+// GetPost handles getting a single post
+func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	viewerID, _ := getUserIDFromContext(r.Context())
+
+	post, err := h.postService.GetPost(r.Context(), viewerID, postID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Post retrieved successfully", post)
+}
+
+// GetUserPosts handles listing a user's posts
+func (h *PostHandler) GetUserPosts(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	viewerID, _ := getUserIDFromContext(r.Context())
+	page, err := parsePageRequest(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	posts, next, err := h.postService.GetUserPosts(r.Context(), viewerID, userID, page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writePageResponse(w, "Posts retrieved successfully", posts, next)
+}
+
+// GetFeed handles the current user's home timeline
+func (h *PostHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	posts, next, err := h.postService.GetFeed(r.Context(), userID, page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writePageResponse(w, "Feed retrieved successfully", posts, next)
+}
+
+// UpdatePost handles editing a post owned by the current user
+func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	postID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	var req model.PostRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	post, err := h.postService.UpdatePost(r.Context(), userID, postID, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Post updated successfully", post)
+}
+
+// DeletePost handles deleting a post owned by the current user
+func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	postID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	if err := h.postService.DeletePost(r.Context(), userID, postID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Post deleted successfully", nil)
+}
+
+// LikePost handles liking a post. Liking twice is a no-op.
+func (h *PostHandler) LikePost(w http.ResponseWriter, r *http.Request) {
+	h.toggleLike(w, r, true)
+}
+
+// UnlikePost handles removing a like from a post. Unliking twice is a no-op.
+func (h *PostHandler) UnlikePost(w http.ResponseWriter, r *http.Request) {
+	h.toggleLike(w, r, false)
+}
+
+// toggleLike applies a like or unlike for the current user
+func (h *PostHandler) toggleLike(w http.ResponseWriter, r *http.Request, like bool) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	postID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	var likeCount int
+	message := "Post liked successfully"
+	if like {
+		likeCount, err = h.postService.LikePost(r.Context(), userID, postID)
+	} else {
+		likeCount, err = h.postService.UnlikePost(r.Context(), userID, postID)
+		message = "Post unliked successfully"
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	response := struct {
+		LikeCount int  `json:"like_count"`
+		IsLiked   bool `json:"is_liked"`
+	}{
+		LikeCount: likeCount,
+		IsLiked:   like,
+	}
+
+	writeSuccessResponse(w, http.StatusOK, message, response)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+)
+
+// problemContentType is the media type of RFC 7807 error responses
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response. Type is about:blank, so
+// Title is the status text; Code is a stable machine-readable reason clients
+// can branch on and Detail explains this occurrence. Extensions are written
+// as extra top-level members, e.g. suspended_until.
+type Problem struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Code       string                 `json:"code"`
+	Fields     []FieldError           `json:"fields,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// problemMembers are the standard members an extension can't replace
+var problemMembers = map[string]bool{
+	"type": true, "title": true, "status": true, "detail": true, "code": true, "fields": true,
+}
+
+// MarshalJSON writes the standard members followed by the extensions
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	members, err := json.Marshal(problem(p))
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := make(map[string]interface{}, len(p.Extensions))
+	for key, value := range p.Extensions {
+		if !problemMembers[key] {
+			extensions[key] = value
+		}
+	}
+	if len(extensions) == 0 {
+		return members, nil
+	}
+
+	extra, err := json.Marshal(extensions)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(members[:len(members)-1], ','), extra[1:]...), nil
+}
+
+// Error codes that aren't carried by a domain error
+const (
+	codeAccountLocked    = "account_locked"
+	codeLoginThrottled   = "login_throttled"
+	codeRateLimited      = "rate_limited"
+	codeValidationFailed = "validation_failed"
+)
+
+// kindStatus maps each kind of domain error to its HTTP status
+var kindStatus = map[error]int{
+	apperr.ErrNotFound:           http.StatusNotFound,
+	apperr.ErrConflict:           http.StatusConflict,
+	apperr.ErrValidation:         http.StatusUnprocessableEntity,
+	apperr.ErrForbidden:          http.StatusForbidden,
+	apperr.ErrUnauthorized:       http.StatusUnauthorized,
+	apperr.ErrPreconditionFailed: http.StatusPreconditionFailed,
+}
+
+// writeError maps err to a problem response. Domain errors get their kind's
+// status with their code and message, plus any apperr.WithDetail values as
+// extensions; anything else is logged and answered with a generic 500 so
+// internal details never reach the client.
+func writeError(w http.ResponseWriter, err error) {
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) {
+		writeServerError(w, err)
+		return
+	}
+
+	status, ok := kindStatus[appErr.Kind]
+	if !ok {
+		writeServerError(w, err)
+		return
+	}
+	writeProblem(w, Problem{
+		Status:     status,
+		Code:       appErr.Code,
+		Detail:     appErr.Message,
+		Extensions: apperr.Details(err),
+	})
+}
+
+// writeProblem writes problem as the response
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+	if problem.Code == "" {
+		problem.Code = statusCode(problem.Status)
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// statusCode derives a code from the status text for errors that don't have
+// their own, e.g. 404 becomes not_found
+func statusCode(status int) string {
+	text := strings.ToLower(http.StatusText(status))
+	if text == "" {
+		return "error"
+	}
+	return strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+)
+
+func TestWriteError(t *testing.T) {
+	errSuspended := apperr.Forbidden("account_suspended", "account is suspended")
+	until := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantExtra  map[string]interface{}
+	}{
+		{"domain error", apperr.NotFound("post_not_found", "post not found"), http.StatusNotFound, "post_not_found", nil},
+		{"wrapped domain error", fmt.Errorf("failed to get post: %w", apperr.NotFound("post_not_found", "post not found")), http.StatusNotFound, "post_not_found", nil},
+		{"with detail", apperr.WithDetail(errSuspended, "suspended_until", until), http.StatusForbidden, "account_suspended",
+			map[string]interface{}{"suspended_until": "2030-01-02T03:04:05Z"}},
+		{"detail can't replace members", apperr.WithDetail(errSuspended, "status", 200), http.StatusForbidden, "account_suspended", nil},
+		{"internal error", errors.New("connection refused"), http.StatusInternalServerError, "internal_server_error", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeError(w, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != problemContentType {
+				t.Errorf("Content-Type = %q, want %q", got, problemContentType)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON %q: %v", w.Body, err)
+			}
+			if body["code"] != tt.wantCode {
+				t.Errorf("code = %v, want %q", body["code"], tt.wantCode)
+			}
+			if body["status"] != float64(tt.wantStatus) {
+				t.Errorf("status member = %v, want %d", body["status"], tt.wantStatus)
+			}
+			for key, want := range tt.wantExtra {
+				if body[key] != want {
+					t.Errorf("%s = %v, want %v", key, body[key], want)
+				}
+			}
+			if tt.wantStatus == http.StatusInternalServerError && body["detail"] == "connection refused" {
+				t.Error("internal error message leaked into the response")
+			}
+		})
+	}
+}
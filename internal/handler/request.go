@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+// maxBodyBytes caps the size of a JSON request body
+const maxBodyBytes = 1 << 20
+
+// validate checks request structs against their validate tags. Fields are
+// reported by their JSON names so errors match what the client sent.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	v.RegisterValidation("notblank", validators.NotBlank)
+	v.RegisterValidation("maxbytes", maxBytes)
+	// For fields where "" clears the value
+	v.RegisterAlias("http_url_or_empty", "len=0|http_url")
+	// Validate a merge patch field through its value pointer, which is nil when
+	// the field was missing or null. omitnil skips only those, so a field that
+	// is present, even as "", always runs its rules.
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(model.Optional[string]).Value
+	}, model.Optional[string]{})
+	return v
+}
+
+// maxBytes limits a string's length in bytes rather than characters, for
+// values like passwords that bcrypt caps at 72 bytes
+func maxBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic(fmt.Sprintf("maxbytes: bad parameter %q", fl.Param()))
+	}
+	return len(fl.Field().String()) <= limit
+}
+
+// FieldError describes why one field of a request body was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// decodeJSON reads the request body into dst and validates it, writing an
+// error response and returning false if the body is malformed, too large,
+// has fields dst doesn't know, or fails validation
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeBody(w, r, dst, false)
+}
+
+// decodeOptionalJSON is decodeJSON for endpoints whose body may be omitted.
+// An empty body leaves dst as is but is still validated.
+func decodeOptionalJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeBody(w, r, dst, true)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, dst interface{}, optional bool) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil {
+		// Anything after the first value means the body isn't one JSON document
+		if dec.Decode(&struct{}{}) != io.EOF {
+			writeErrorResponse(w, http.StatusBadRequest, "Request body must contain a single JSON value")
+			return false
+		}
+	} else if !(optional && errors.Is(err, io.EOF)) {
+		writeDecodeError(w, err)
+		return false
+	}
+
+	if fields := validateRequest(dst); len(fields) > 0 {
+		writeValidationError(w, fields)
+		return false
+	}
+
+	return true
+}
+
+// writeDecodeError maps a JSON decoding failure to a 400, or a 413 if the
+// body was over the size limit
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		writeErrorResponse(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, io.EOF):
+		writeErrorResponse(w, http.StatusBadRequest, "Request body must not be empty")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		writeErrorResponse(w, http.StatusBadRequest, "Request body contains malformed JSON")
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Field %q has the wrong type", typeErr.Field))
+			return
+		}
+		writeErrorResponse(w, http.StatusBadRequest, "Request body has the wrong type")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Unknown field %s", field))
+	default:
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	}
+}
+
+// validateRequest runs dst's validate tags and returns the failing fields.
+// Anything other than a struct, such as a map, has no tags and always passes.
+func validateRequest(dst interface{}) []FieldError {
+	v := reflect.ValueOf(dst)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := validate.Struct(dst)
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, FieldError{Field: fieldPath(fe), Message: fieldMessage(fe)})
+	}
+	return fields
+}
+
+// fieldPath is the field's JSON path without the top-level struct name
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+// fieldMessage describes a failed validate tag in words
+func fieldMessage(fe validator.FieldError) string {
+	unit := "characters"
+	switch fe.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		unit = ""
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "http_url_or_empty":
+		return "must be a valid URL or empty"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		if unit == "" {
+			return "must be at least " + fe.Param()
+		}
+		return fmt.Sprintf("must be at least %s %s", fe.Param(), unit)
+	case "max":
+		if unit == "" {
+			return "must be at most " + fe.Param()
+		}
+		return fmt.Sprintf("must be at most %s %s", fe.Param(), unit)
+	case "maxbytes":
+		return fmt.Sprintf("must be at most %s bytes", fe.Param())
+	case "len":
+		return fmt.Sprintf("must be exactly %s %s", fe.Param(), unit)
+	default:
+		return "is invalid"
+	}
+}
+
+// writeValidationError writes a 422 listing each field that failed validation
+func writeValidationError(w http.ResponseWriter, fields []FieldError) {
+	writeProblem(w, Problem{
+		Status: http.StatusUnprocessableEntity,
+		Code:   codeValidationFailed,
+		Detail: "Request body failed validation",
+		Fields: fields,
+	})
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+func TestDecodeJSONUserRequest(t *testing.T) {
+	user := func(password string) string {
+		return `{"username":"jane","email":"jane@example.com","password":"` + password + `","full_name":"Jane Doe"}`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"valid", user("secret"), http.StatusOK},
+		{"password too short", user("abc"), http.StatusUnprocessableEntity},
+		{"password at bcrypt limit", user(strings.Repeat("a", 72)), http.StatusOK},
+		{"password over bcrypt limit", user(strings.Repeat("a", 73)), http.StatusUnprocessableEntity},
+		// 36 two-byte characters is 72 bytes, 37 is over even though it's under 72 characters
+		{"multibyte password at limit", user(strings.Repeat("é", 36)), http.StatusOK},
+		{"multibyte password over limit", user(strings.Repeat("é", 37)), http.StatusUnprocessableEntity},
+		{"missing email", `{"username":"jane","password":"secret","full_name":"Jane Doe"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"username":"jane","role":"admin"}`, http.StatusBadRequest},
+		{"wrong type", `{"username":42}`, http.StatusBadRequest},
+		{"malformed", `{"username":`, http.StatusBadRequest},
+		{"two values", user("secret") + ` {}`, http.StatusBadRequest},
+		{"no body", ``, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			var req model.UserRequest
+			ok := decodeJSON(w, r, &req)
+
+			if ok != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("decodeJSON = %v, want %v (response %d %s)", ok, !ok, w.Code, w.Body)
+			}
+			if !ok && w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONPostRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"content only", `{"content":"hello"}`, http.StatusOK},
+		{"image", `{"content":"hello","image_url":"https://cdn.example.com/a.png"}`, http.StatusOK},
+		{"image empty", `{"content":"hello","image_url":""}`, http.StatusOK},
+		{"image not a url", `{"content":"hello","image_url":"a.png"}`, http.StatusUnprocessableEntity},
+		{"image not http", `{"content":"hello","image_url":"javascript:alert(1)"}`, http.StatusUnprocessableEntity},
+		{"image too long", `{"content":"hello","image_url":"https://example.com/` + strings.Repeat("a", 250) + `"}`, http.StatusUnprocessableEntity},
+		{"blank content", `{"content":"   "}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			var req model.PostRequest
+			ok := decodeJSON(w, r, &req)
+
+			if ok != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("decodeJSON = %v, want %v (response %d %s)", ok, !ok, w.Code, w.Body)
+			}
+			if !ok && w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONUpdateProfileRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"empty patch", `{}`, http.StatusOK},
+		{"full name", `{"full_name":"Jane Doe"}`, http.StatusOK},
+		{"full name null", `{"full_name":null}`, http.StatusOK},
+		{"full name empty", `{"full_name":""}`, http.StatusUnprocessableEntity},
+		{"full name too short", `{"full_name":"Jo"}`, http.StatusUnprocessableEntity},
+		{"full name too long", `{"full_name":"` + strings.Repeat("a", 51) + `"}`, http.StatusUnprocessableEntity},
+		{"bio cleared", `{"bio":""}`, http.StatusOK},
+		{"bio null", `{"bio":null}`, http.StatusOK},
+		{"bio too long", `{"bio":"` + strings.Repeat("a", 161) + `"}`, http.StatusUnprocessableEntity},
+		{"avatar", `{"avatar":"https://cdn.example.com/a.png"}`, http.StatusOK},
+		{"avatar cleared", `{"avatar":""}`, http.StatusOK},
+		{"avatar not a url", `{"avatar":"a.png"}`, http.StatusUnprocessableEntity},
+		{"avatar not http", `{"avatar":"ftp://example.com/a.png"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"email":"x@example.com"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			var req model.UpdateProfileRequest
+			ok := decodeJSON(w, r, &req)
+
+			if ok != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("decodeJSON = %v, want %v (response %d %s)", ok, !ok, w.Code, w.Body)
+			}
+			if !ok && w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONTooLarge(t *testing.T) {
+	body := `{"content":"` + strings.Repeat("a", maxBodyBytes) + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	var req model.PostRequest
+	if decodeJSON(w, r, &req) {
+		t.Fatal("decodeJSON accepted a body over the limit")
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
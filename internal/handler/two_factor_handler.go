@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/naval1525/Social_Media_Backend/internal/service"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// Enroll handles starting authenticator app setup for the current user
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Scan the URI with your authenticator app, then confirm with a code", enrollment)
+}
+
+// Confirm handles activating two-factor authentication with a first code
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(r.Context(), userID, req.Code, clientInfoFromRequest(r))
+	if err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	response := struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: codes,
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Two-factor authentication enabled. Store these recovery codes safely", response)
+}
+
+// Disable handles turning off two-factor authentication
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" validate:"required"`
+		Code     string `json:"code" validate:"required"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), userID, req.Password, req.Code, clientInfoFromRequest(r)); err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// Verify handles the second login step: a challenge token plus a TOTP or recovery code
+func (h *TwoFactorHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" validate:"required"`
+		Code           string `json:"code" validate:"required"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	result, err := h.twoFactorService.CompleteLogin(r.Context(), req.ChallengeToken, req.Code, clientInfoFromRequest(r))
+	if err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	writeLoginResponse(w, result)
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
 	"github.com/naval1525/Social_Media_Backend/internal/service"
 )
 
@@ -23,20 +27,13 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 // Register handles user registration
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req model.UserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		return
-	}
-
-	// Basic validation
-	if req.Username == "" || req.Email == "" || req.Password == "" || req.FullName == "" {
-		writeErrorResponse(w, http.StatusBadRequest, "All fields are required")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	user, err := h.userService.Register(r.Context(), &req)
 	if err != nil {
-		writeErrorResponse(w, http.StatusConflict, err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -46,32 +43,65 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 // Login handles user authentication
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if req.Email == "" || req.Password == "" {
-		writeErrorResponse(w, http.StatusBadRequest, "Email and password are required")
+	result, err := h.userService.Login(r.Context(), req.Email, req.Password, clientInfoFromRequest(r))
+	if err != nil {
+		writeLoginError(w, err)
 		return
 	}
 
-	user, token, err := h.userService.Login(r.Context(), req.Email, req.Password)
-	if err != nil {
-		writeErrorResponse(w, http.StatusUnauthorized, err.Error())
+	writeLoginResponse(w, result)
+}
+
+// writeLoginError maps failures of login and of the two-factor checks behind
+// the same brute-force protection. Throttled attempts get 429 with
+// Retry-After; everything else goes through writeError.
+func writeLoginError(w http.ResponseWriter, err error) {
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		writeError(w, err)
+		return
+	}
+
+	code := codeLoginThrottled
+	if errors.Is(err, service.ErrAccountLocked) {
+		code = codeAccountLocked
+	}
+	w.Header().Set("Retry-After", ceilSeconds(throttled.RetryAfter))
+	writeErrorCode(w, http.StatusTooManyRequests, code, err.Error())
+}
+
+// writeLoginResponse writes either the signed-in user with tokens, or the
+// challenge the client must answer with a two-factor code
+func writeLoginResponse(w http.ResponseWriter, result *model.LoginResult) {
+	if result.MFARequired {
+		response := struct {
+			MFARequired    bool   `json:"mfa_required"`
+			ChallengeToken string `json:"challenge_token"`
+		}{
+			MFARequired:    true,
+			ChallengeToken: result.ChallengeToken,
+		}
+
+		writeSuccessResponse(w, http.StatusOK, "Two-factor authentication required", response)
 		return
 	}
 
 	response := struct {
 		User  *model.UserResponse `json:"user"`
-		Token string              `json:"token"`
+		Token string              `json:"token"` // same as access_token, kept for existing clients
+		*model.TokenPair
 	}{
-		User:  user,
-		Token: token,
+		User:      result.User,
+		Token:     result.Tokens.AccessToken,
+		TokenPair: result.Tokens,
 	}
 
 	writeSuccessResponse(w, http.StatusOK, "Login successful", response)
@@ -88,9 +118,23 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.GetProfile(r.Context(), userID)
+	viewerID, _ := getUserIDFromContext(r.Context())
+	user, err := h.userService.GetProfile(r.Context(), viewerID, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Profile retrieved successfully", user)
+}
+
+// GetProfileByUsername handles getting a user's public profile by username,
+// including a previous username that is still held for them
+func (h *UserHandler) GetProfileByUsername(w http.ResponseWriter, r *http.Request) {
+	viewerID, _ := getUserIDFromContext(r.Context())
+	user, err := h.userService.GetProfileByUsername(r.Context(), viewerID, mux.Vars(r)["username"])
 	if err != nil {
-		writeErrorResponse(w, http.StatusNotFound, "User not found")
+		writeError(w, err)
 		return
 	}
 
@@ -105,16 +149,42 @@ func (h *UserHandler) GetMyProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.GetProfile(r.Context(), userID)
+	user, err := h.userService.GetProfile(r.Context(), userID, userID)
 	if err != nil {
-		writeErrorResponse(w, http.StatusNotFound, "User not found")
+		writeError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", profileETag(user.UpdatedAt))
 	writeSuccessResponse(w, http.StatusOK, "Profile retrieved successfully", user)
 }
 
-// UpdateProfile handles updating user profile
+// GetLoginHistory handles listing the current user's recent login attempts
+func (h *UserHandler) GetLoginHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+
+	attempts, next, err := h.userService.ListLoginHistory(r.Context(), userID, page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writePageResponse(w, "Login history retrieved successfully", attempts, next)
+}
+
+// UpdateProfile handles a JSON Merge Patch of the current user's profile. An
+// If-Match header holding the ETag from a previous read makes the update fail
+// with 412 if the profile has changed since.
 func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromContext(r.Context())
 	if err != nil {
@@ -122,17 +192,99 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	ifUpdatedAt, ok := parseIfMatch(r)
+	if !ok {
+		writeError(w, repository.ErrUserModified)
 		return
 	}
 
-	user, err := h.userService.UpdateProfile(r.Context(), userID, updates)
+	var req model.UpdateProfileRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), userID, &req, ifUpdatedAt)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		writeError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", profileETag(user.UpdatedAt))
 	writeSuccessResponse(w, http.StatusOK, "Profile updated successfully", user)
 }
+
+// ChangeUsername handles giving the current user a new username
+func (h *UserHandler) ChangeUsername(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Username string `json:"username" validate:"required,min=3,max=30"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.userService.ChangeUsername(r.Context(), userID, req.Username)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", profileETag(user.UpdatedAt))
+	writeSuccessResponse(w, http.StatusOK, "Username changed successfully", user)
+}
+
+// DeleteAccount handles scheduling the current user's account for deletion.
+// The password is required again so a stolen session can't delete an account.
+func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" validate:"required"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	deleteAt, err := h.userService.DeleteAccount(r.Context(), userID, req.Password)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusAccepted, "Account scheduled for deletion; log in before then to cancel", map[string]time.Time{
+		"deletion_scheduled_at": deleteAt,
+	})
+}
+
+// profileETag identifies a version of a profile by its updated_at, at the
+// microsecond precision Postgres stores
+func profileETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// parseIfMatch reads the update time a request is conditional on from If-Match.
+// It returns nil when there's no condition, and false if the header can't match
+// any profile version, such as a weak or foreign ETag.
+func parseIfMatch(r *http.Request) (*time.Time, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	micros, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return nil, false
+	}
+
+	updatedAt := time.UnixMicro(micros)
+	return &updatedAt, true
+}
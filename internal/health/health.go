@@ -0,0 +1,155 @@
+// Package health runs the dependency checks behind the readiness probe
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/naval1525/Social_Media_Backend/internal/database"
+)
+
+// Check probes one dependency and returns an error if it isn't usable. It may
+// also return details to include in the report.
+type Check func(ctx context.Context) (map[string]interface{}, error)
+
+// Statuses used in reports
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Result is the outcome of a single check
+type Result struct {
+	Status    string                 `json:"status"`
+	LatencyMS float64                `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report is the outcome of every registered check. Status is ok only if all
+// checks passed.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Healthy reports whether every check passed
+func (r *Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// Checker holds the registered checks
+type Checker struct {
+	timeout time.Duration
+	mu      sync.RWMutex
+	checks  map[string]Check
+}
+
+// NewChecker creates a checker that gives each check at most timeout to finish
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Register adds check under name, replacing any check already registered with it
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Run executes all checks concurrently and collects their results
+func (c *Checker) Run(ctx context.Context) *Report {
+	c.mu.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	report := &Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check(ctx)
+	result := Result{
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = fmt.Sprintf("timed out after %s", c.timeout)
+		}
+	}
+
+	return result
+}
+
+// Database checks that the database answers a ping and reports pool usage
+func Database(db *database.DB) Check {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return nil, err
+		}
+
+		stats := db.Stats()
+		return map[string]interface{}{
+			"open_connections": stats.OpenConnections,
+			"in_use":           stats.InUse,
+		}, nil
+	}
+}
+
+// Migrations checks that the schema is at the newest migration and that the
+// last migration didn't fail partway
+func Migrations(db *database.DB) Check {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		status, err := db.MigrationStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		details := map[string]interface{}{
+			"version": status.Version,
+			"latest":  status.Latest,
+			"dirty":   status.Dirty,
+		}
+		switch {
+		case status.Dirty:
+			return details, fmt.Errorf("migration %d is dirty", status.Version)
+		case status.Version < status.Latest:
+			return details, fmt.Errorf("schema is at version %d, expected %d", status.Version, status.Latest)
+		}
+
+		return details, nil
+	}
+}
@@ -0,0 +1,197 @@
+// Package jwtkeys holds the keys used to sign and verify JWTs and publishes the
+// public halves as a JSON Web Key Set.
+//
+// Keys are loaded from a directory of PEM files named <kid>.pem. Each file holds
+// either a private key (RSA or Ed25519, PKCS#8 or PKCS#1) or just a public key.
+// New tokens are signed with the active key; every other key in the directory
+// still verifies tokens until they expire. To rotate, add the new key, make it
+// active, and remove the old file once the longest-lived token signed with it
+// has expired.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Key is one signing key, identified by its kid
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{} // nil for verify-only keys
+	verifyKey interface{}
+}
+
+// KeyRing signs with one active key and verifies with any key it holds
+type KeyRing struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewHMACKeyRing returns a ring holding a single shared HS256 secret. It has
+// no public keys to publish, so other services can't verify its tokens.
+func NewHMACKeyRing(secret string) *KeyRing {
+	key := &Key{
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+	return &KeyRing{active: key, keys: map[string]*Key{"": key}}
+}
+
+// LoadDir reads every <kid>.pem file in dir and signs with activeKID
+func LoadDir(dir, activeKID string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	ring := &KeyRing{keys: make(map[string]*Key, len(paths))}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := loadKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[kid] = key
+	}
+
+	active, ok := ring.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found in %s", activeKID, dir)
+	}
+	if active.signKey == nil {
+		return nil, fmt.Errorf("active signing key %q has no private key", activeKID)
+	}
+	ring.active = active
+
+	return ring, nil
+}
+
+// Sign signs claims with the active key, setting the kid header
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(r.active.Method, claims)
+	if r.active.ID != "" {
+		token.Header["kid"] = r.active.ID
+	}
+	return token.SignedString(r.active.signKey)
+}
+
+// Keyfunc picks the verification key named by the token's kid, rejecting
+// tokens whose alg doesn't match that key
+func (r *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys in the ring, sorted by kid
+func (r *KeyRing) JWKS() *JWKSet {
+	set := &JWKSet{Keys: []JWK{}}
+	for _, key := range r.keys {
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+// loadKey parses a PEM file holding a private or public RSA or Ed25519 key
+func loadKey(kid, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key %q: %w", kid, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q is not PEM encoded", kid)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("signing key %q has unsupported PEM type %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %q: %w", kid, err)
+	}
+
+	key := &Key{ID: kid}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.signKey = signer
+		parsed = signer.Public()
+	}
+
+	switch parsed.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("signing key %q must be RSA or Ed25519", kid)
+	}
+	key.verifyKey = parsed
+
+	return key, nil
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogMailer is a development mailer. It logs every message and, when Dir is set,
+// also writes each one to an .eml file there so links can be opened locally.
+type LogMailer struct {
+	From string
+	Dir  string
+}
+
+// NewLogMailer creates a new log mailer
+func NewLogMailer(from, dir string) *LogMailer {
+	return &LogMailer{From: from, Dir: dir}
+}
+
+// Send logs msg and optionally writes it to disk
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "mail", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+
+	if m.Dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), sanitizeFilename(msg.To))
+	if err := os.WriteFile(filepath.Join(m.Dir, name), formatMessage(m.From, msg), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+
+	return nil
+}
+
+// sanitizeFilename keeps an address usable as part of a file name
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '@', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,120 @@
+// Package metrics defines the Prometheus metrics the server exports at /metrics.
+// Metrics are registered on the default registry, so the Go runtime and
+// process collectors come along for free.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "social_media"
+
+// Login results, used as the "result" label on the logins counter
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	registrations = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Accounts registered.",
+	})
+
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	posts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "Posts created.",
+	})
+
+	likes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "likes_total",
+		Help:      "Likes added; repeated likes of the same post are not counted.",
+	})
+
+	follows = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "follows_total",
+		Help:      "Follows added; repeated follows of the same user are not counted.",
+	})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected by rate limiting, by policy.",
+	}, []string{"policy"})
+)
+
+func init() {
+	// Make the result series visible before the first login
+	logins.WithLabelValues(LoginSuccess)
+	logins.WithLabelValues(LoginFailure)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDB exports the connection pool statistics of db as gauges labeled with name
+func RegisterDB(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveHTTPRequest records a completed request. Route should be the mux path
+// template rather than the raw path, so IDs don't create a series each.
+func ObserveHTTPRequest(method, route string, status int, latency time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(latency.Seconds())
+}
+
+// Registration counts a new account
+func Registration() { registrations.Inc() }
+
+// Login counts a login attempt with result LoginSuccess or LoginFailure
+func Login(result string) { logins.WithLabelValues(result).Inc() }
+
+// PostCreated counts a new post
+func PostCreated() { posts.Inc() }
+
+// Like counts a new like; repeating a like doesn't count again
+func Like() { likes.Inc() }
+
+// Follow counts a new follow; repeating a follow doesn't count again
+func Follow() { follows.Inc() }
+
+// RateLimited counts a request rejected under the named rate limit policy
+func RateLimited(policy string) { rateLimited.WithLabelValues(policy).Inc() }
@@ -20,5 +20,5 @@ type Comment struct {
 
 // CommentRequest represents the JSON structure for creating comments
 type CommentRequest struct {
-	Content string `json:"content" validate:"required,max=500"`
+	Content string `json:"content" validate:"required,notblank,max=500"`
 }
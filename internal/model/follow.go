@@ -7,10 +7,10 @@ import (
 )
 
 type Follow struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	FollowerID  uuid.UUID `json:"follower_id" db:"follower_id"`   // User who follows
-	FollowingID uuid.UUID `json:"following_id" db:"following_id"` // User being followed
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ID         uuid.UUID `json:"id" db:"id"`
+	FollowerID uuid.UUID `json:"follower_id" db:"follower_id"` // User who follows
+	FollowedID uuid.UUID `json:"followed_id" db:"followed_id"` // User being followed
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 // Like represents a like on a post
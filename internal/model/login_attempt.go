@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginAttempt records one attempt to sign in. UserID is nil when the email
+// matched no account.
+type LoginAttempt struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    *uuid.UUID `json:"-" db:"user_id"`
+	IPAddress string     `json:"ip_address" db:"ip_address"`
+	UserAgent string     `json:"user_agent" db:"user_agent"`
+	Success   bool       `json:"success" db:"success"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
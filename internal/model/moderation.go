@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModerationActionType is what a moderation action did to an account
+type ModerationActionType string
+
+const (
+	ModerationSuspend   ModerationActionType = "suspend"
+	ModerationUnsuspend ModerationActionType = "unsuspend"
+	ModerationBan       ModerationActionType = "ban"
+	ModerationUnban     ModerationActionType = "unban"
+)
+
+// ModerationAction records a suspension, ban or reversal of one. ActorID is nil
+// once the staff account that took the action has been deleted.
+type ModerationAction struct {
+	ID        uuid.UUID            `json:"id" db:"id"`
+	UserID    uuid.UUID            `json:"user_id" db:"user_id"`
+	ActorID   *uuid.UUID           `json:"actor_id,omitempty" db:"actor_id"`
+	Action    ModerationActionType `json:"action" db:"action"`
+	Reason    string               `json:"reason" db:"reason"`
+	ExpiresAt *time.Time           `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt time.Time            `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks a position in a list ordered by (created_at, id).
+// Clients only ever see it in its opaque encoded form.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// PageRequest describes which page of a keyset-paginated list to read.
+// A nil After starts from the beginning of the list.
+type PageRequest struct {
+	Limit int
+	After *Cursor
+}
+
+// Encode returns the opaque string form of the cursor
+func (c *Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor previously produced by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	c := &Cursor{}
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return c, nil
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := &Cursor{
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC),
+		ID:        uuid.MustParse("6f1c2a4e-8d1b-4a4f-9c57-2f0d9e3b7a10"),
+	}
+
+	got, err := DecodeCursor(want.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("DecodeCursor(Encode()) = %+v, want %+v", got, want)
+	}
+}
+
+func TestCursorEncodeNormalizesToUTC(t *testing.T) {
+	local := time.Date(2024, 3, 1, 14, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	c := &Cursor{CreatedAt: local, ID: uuid.New()}
+
+	got, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if got.CreatedAt.Location() != time.UTC || !got.CreatedAt.Equal(local) {
+		t.Errorf("CreatedAt = %v, want %v in UTC", got.CreatedAt, local)
+	}
+}
+
+func TestDecodeCursorRejectsTamperedInput(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	valid := (&Cursor{CreatedAt: time.Now(), ID: uuid.New()}).Encode()
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"standard alphabet", "+/" + valid},
+		{"truncated", valid[:len(valid)-3]},
+		{"no separator", encode("2024-03-01T12:00:00Z")},
+		{"bad time", encode("yesterday|" + uuid.NewString())},
+		{"bad id", encode("2024-03-01T12:00:00Z|1234")},
+		{"extra field", encode("2024-03-01T12:00:00Z|" + uuid.NewString() + "|x")},
+		{"swapped fields", encode(uuid.NewString() + "|2024-03-01T12:00:00Z")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := DecodeCursor(tt.cursor); err == nil {
+				t.Errorf("DecodeCursor(%q) = %+v, want error", tt.cursor, c)
+			}
+		})
+	}
+}
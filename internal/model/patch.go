@@ -0,0 +1,31 @@
+package model
+
+import "encoding/json"
+
+// Optional is a field of a JSON Merge Patch (RFC 7396) document. Set reports
+// whether the field appeared at all, so a missing field leaves the stored
+// value alone, while Value is nil when the field was null, which asks for it
+// to be cleared.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// UnmarshalJSON records that the field was present. encoding/json only calls
+// it for fields in the document, including ones set to null.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Or returns the value, or fallback if the field was null or missing
+func (o Optional[T]) Or(fallback T) T {
+	if o.Value == nil {
+		return fallback
+	}
+	return *o.Value
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOptionalUnmarshal(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantSet   bool
+		wantValue *string
+	}{
+		{"absent", `{}`, false, nil},
+		{"null", `{"bio":null}`, true, nil},
+		{"empty string", `{"bio":""}`, true, ptr("")},
+		{"value", `{"bio":"hello"}`, true, ptr("hello")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req struct {
+				Bio Optional[string] `json:"bio"`
+			}
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			if req.Bio.Set != tt.wantSet {
+				t.Errorf("Set = %v, want %v", req.Bio.Set, tt.wantSet)
+			}
+			switch {
+			case tt.wantValue == nil && req.Bio.Value != nil:
+				t.Errorf("Value = %q, want nil", *req.Bio.Value)
+			case tt.wantValue != nil && (req.Bio.Value == nil || *req.Bio.Value != *tt.wantValue):
+				t.Errorf("Value = %v, want %q", req.Bio.Value, *tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestOptionalUnmarshalWrongType(t *testing.T) {
+	var req struct {
+		Bio Optional[string] `json:"bio"`
+	}
+	if err := json.Unmarshal([]byte(`{"bio":5}`), &req); err == nil {
+		t.Error("Unmarshal of a number into Optional[string] succeeded, want error")
+	}
+}
+
+func TestOptionalOr(t *testing.T) {
+	if got := (Optional[string]{}).Or("fallback"); got != "fallback" {
+		t.Errorf("absent Or = %q, want fallback", got)
+	}
+	if got := (Optional[string]{Set: true}).Or("fallback"); got != "fallback" {
+		t.Errorf("null Or = %q, want fallback", got)
+	}
+	if got := (Optional[string]{Set: true, Value: ptr("")}).Or("fallback"); got != "" {
+		t.Errorf("empty Or = %q, want empty", got)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
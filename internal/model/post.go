@@ -8,13 +8,14 @@ import (
 
 // Post represents a social media post
 type Post struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	Content   string    `json:"content" db:"content"`
-	ImageURL  string    `json:"image_url" db:"image_url"`
-	LikeCount int       `json:"like_count" db:"like_count"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	Content      string    `json:"content" db:"content"`
+	ImageURL     string    `json:"image_url" db:"image_url"`
+	LikeCount    int       `json:"like_count" db:"like_count"`
+	CommentCount int       `json:"comment_count" db:"comment_count"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 
 	// Joined fields (not stored in DB, populated via JOINs)
 	Author  *UserResponse `json:"author,omitempty"`
@@ -23,6 +24,6 @@ type Post struct {
 
 // PostRequest represents the JSON structure for creating posts
 type PostRequest struct {
-	Content  string `json:"content" validate:"required,max=500"`
-	ImageURL string `json:"image_url"`
+	Content  string `json:"content" validate:"required,notblank,max=500"`
+	ImageURL string `json:"image_url" validate:"omitempty,max=255,http_url"`
 }
@@ -0,0 +1,62 @@
+package model
+
+import "github.com/google/uuid"
+
+// Role is a user's access level. Each role includes the permissions of the ones below it.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Permission names an operation guarded by RequirePermission
+type Permission string
+
+const (
+	PermissionListUsers    Permission = "users:list"
+	PermissionSuspendUsers Permission = "users:suspend"
+	PermissionBanUsers     Permission = "users:ban"
+	PermissionDeleteUsers  Permission = "users:delete"
+	PermissionManageRoles  Permission = "users:manage_roles"
+)
+
+// roleRanks orders roles so a moderator can't act on another moderator or an admin
+var roleRanks = map[Role]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+var rolePermissions = map[Role][]Permission{
+	RoleModerator: {PermissionListUsers, PermissionSuspendUsers},
+	RoleAdmin:     {PermissionListUsers, PermissionSuspendUsers, PermissionBanUsers, PermissionDeleteUsers, PermissionManageRoles},
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Can reports whether the role grants permission p
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Outranks reports whether r is strictly above other
+func (r Role) Outranks(other Role) bool {
+	return roleRanks[r] > roleRanks[other]
+}
+
+// Principal is the authenticated caller, as carried by an access token
+type Principal struct {
+	UserID uuid.UUID
+	Role   Role
+}
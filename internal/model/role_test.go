@@ -0,0 +1,59 @@
+package model
+
+import "testing"
+
+func TestRoleOutranks(t *testing.T) {
+	tests := []struct {
+		role, other Role
+		want        bool
+	}{
+		{RoleAdmin, RoleModerator, true},
+		{RoleAdmin, RoleUser, true},
+		{RoleModerator, RoleUser, true},
+		{RoleAdmin, RoleAdmin, false},
+		{RoleModerator, RoleModerator, false},
+		{RoleUser, RoleUser, false},
+		{RoleModerator, RoleAdmin, false},
+		{RoleUser, RoleModerator, false},
+		// An unknown role ranks with users
+		{RoleModerator, Role("owner"), true},
+		{Role("owner"), RoleUser, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Outranks(tt.other); got != tt.want {
+			t.Errorf("%q.Outranks(%q) = %v, want %v", tt.role, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestRoleCan(t *testing.T) {
+	all := []Permission{PermissionListUsers, PermissionSuspendUsers, PermissionBanUsers, PermissionDeleteUsers, PermissionManageRoles}
+	granted := map[Role]map[Permission]bool{
+		RoleUser:      {},
+		RoleModerator: {PermissionListUsers: true, PermissionSuspendUsers: true},
+		RoleAdmin:     {PermissionListUsers: true, PermissionSuspendUsers: true, PermissionBanUsers: true, PermissionDeleteUsers: true, PermissionManageRoles: true},
+		Role("owner"): {},
+	}
+
+	for role, perms := range granted {
+		for _, p := range all {
+			if got := role.Can(p); got != perms[p] {
+				t.Errorf("%q.Can(%q) = %v, want %v", role, p, got, perms[p])
+			}
+		}
+	}
+}
+
+func TestRoleValid(t *testing.T) {
+	for _, r := range []Role{RoleUser, RoleModerator, RoleAdmin} {
+		if !r.Valid() {
+			t.Errorf("%q.Valid() = false, want true", r)
+		}
+	}
+	for _, r := range []Role{"", "owner", "Admin"} {
+		if r.Valid() {
+			t.Errorf("%q.Valid() = true, want false", r)
+		}
+	}
+}
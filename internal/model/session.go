@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a single refresh token. Tokens issued by rotating one another share a FamilyID,
+// which is also the "sid" claim carried by access tokens.
+type Session struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	FamilyID  uuid.UUID  `json:"family_id" db:"family_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	UserAgent string     `json:"user_agent" db:"user_agent"`
+	IPAddress string     `json:"ip_address" db:"ip_address"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// TokenPair is the access/refresh token pair returned by login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // access token lifetime in seconds
+}
+
+// LoginResult is the outcome of a password login. When the account has two-factor
+// authentication enabled, Tokens is nil and the client must post a code together
+// with ChallengeToken to finish signing in.
+type LoginResult struct {
+	User           *UserResponse
+	Tokens         *TokenPair
+	MFARequired    bool
+	ChallengeToken string
+}
+
+// TOTPEnrollment is returned when a user starts setting up an authenticator app
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// ClientInfo describes the client a session was created from
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
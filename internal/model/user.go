@@ -8,31 +8,127 @@ import (
 
 // User represents a user in our social media platform
 type User struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Username  string    `json:"username" db:"username"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"-" db:"password_hash"` // "-" means don't include in JSON
-	FullName  string    `json:"full_name" db:"full_name"`
-	Bio       string    `json:"bio" db:"bio"`
-	Avatar    string    `json:"avatar" db:"avatar"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID              uuid.UUID  `json:"id" db:"id"`
+	Username        string     `json:"username" db:"username"`
+	Email           string     `json:"email" db:"email"`
+	Password        string     `json:"-" db:"password_hash"` // "-" means don't include in JSON
+	FullName        string     `json:"full_name" db:"full_name"`
+	Bio             string     `json:"bio" db:"bio"`
+	Avatar          string     `json:"avatar" db:"avatar"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	TOTPSecret      string     `json:"-" db:"totp_secret"`
+	TOTPEnabledAt   *time.Time `json:"-" db:"totp_enabled_at"`
+	Role            Role       `json:"role" db:"role"`
+	SuspendedUntil  *time.Time `json:"suspended_until,omitempty" db:"suspended_until"`
+	BannedAt        *time.Time `json:"banned_at,omitempty" db:"banned_at"`
+	// FailedLoginAttempts counts consecutive failures since the last success or lockout
+	FailedLoginAttempts int        `json:"-" db:"failed_login_attempts"`
+	LastFailedLoginAt   *time.Time `json:"-" db:"last_failed_login_at"`
+	LockedUntil         *time.Time `json:"-" db:"locked_until"`
+	UsernameChangedAt   *time.Time `json:"-" db:"username_changed_at"`
+	// DeletionScheduledAt is when a requested account deletion takes effect
+	DeletionScheduledAt *time.Time `json:"-" db:"deletion_scheduled_at"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 type UserRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=30"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6,max=100"`
+	Password string `json:"password" validate:"required,min=6,max=72,maxbytes=72"`
 	FullName string `json:"full_name" validate:"required,min=3,max=50"`
 }
 
-// UserResponse represents the JSON response (wihtout sensitive data)
+// UpdateProfileRequest is a JSON Merge Patch of the caller's profile. Fields
+// left out are unchanged; bio and avatar may be set to null or "" to clear
+// them, but full_name can't be cleared.
+type UpdateProfileRequest struct {
+	FullName Optional[string] `json:"full_name" validate:"omitnil,min=3,max=50"`
+	Bio      Optional[string] `json:"bio" validate:"omitnil,max=160"`
+	Avatar   Optional[string] `json:"avatar" validate:"omitnil,max=255,http_url_or_empty"`
+}
+
+// UserResponse represents the JSON response (wihtout sensitive data). The
+// account details after Avatar are only filled in for the account owner and
+// staff, so other users' views never reveal them.
 type UserResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	FullName  string    `json:"full_name"`
-	Bio       string    `json:"bio"`
-	Avatar    string    `json:"avatar"`
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	FullName string    `json:"full_name"`
+	Bio      string    `json:"bio"`
+	Avatar   string    `json:"avatar"`
+
+	Email               string     `json:"email,omitempty"`
+	EmailVerified       *bool      `json:"email_verified,omitempty"`
+	TwoFactorEnabled    *bool      `json:"two_factor_enabled,omitempty"`
+	Role                Role       `json:"role,omitempty"`
+	SuspendedUntil      *time.Time `json:"suspended_until,omitempty"`
+	Banned              bool       `json:"banned,omitempty"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Aggregated fields, only present on profile lookups so embedded users
+	// don't show zero counts. IsFollowing reports whether the signed-in viewer
+	// follows this user and is left out for anonymous viewers and the owner.
+	PostCount      *int  `json:"post_count,omitempty"`
+	FollowerCount  *int  `json:"follower_count,omitempty"`
+	FollowingCount *int  `json:"following_count,omitempty"`
+	IsFollowing    *bool `json:"is_following,omitempty"`
+}
+
+// ToResponse converts a user into its public JSON representation
+func (u *User) ToResponse() *UserResponse {
+	return &UserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		FullName:  u.FullName,
+		Bio:       u.Bio,
+		Avatar:    u.Avatar,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+// ToOwnerResponse converts a user into the representation shown to the
+// account owner, which adds their account details: email, verification and
+// two-factor status, role, restrictions and any pending deletion. Staff get
+// the same view in the admin API.
+func (u *User) ToOwnerResponse() *UserResponse {
+	emailVerified := u.EmailVerifiedAt != nil
+	twoFactorEnabled := u.TOTPEnabledAt != nil
+
+	response := u.ToResponse()
+	response.Email = u.Email
+	response.EmailVerified = &emailVerified
+	response.TwoFactorEnabled = &twoFactorEnabled
+	response.Role = u.Role
+	response.Banned = u.IsBanned()
+	if u.IsSuspended(time.Now()) {
+		response.SuspendedUntil = u.SuspendedUntil
+	}
+	response.DeletionScheduledAt = u.DeletionScheduledAt
+	return response
+}
+
+// IsSuspended reports whether a suspension is in effect at now
+func (u *User) IsSuspended(now time.Time) bool {
+	return u.SuspendedUntil != nil && now.Before(*u.SuspendedUntil)
+}
+
+// IsBanned reports whether the user is banned
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
+}
+
+// IsDeleted reports whether the account's deletion grace period has run out
+// at now. It counts as deleted from then on, even before the purge removes it.
+func (u *User) IsDeleted(now time.Time) bool {
+	return u.DeletionScheduledAt != nil && !now.Before(*u.DeletionScheduledAt)
+}
+
+// IsLocked reports whether a lockout after failed logins is in effect at now
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
 }
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenPurpose distinguishes what a single-use user token may be redeemed for
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+)
+
+// UserToken is a single-use, expiring token emailed to a user. Only its hash is stored.
+type UserToken struct {
+	ID        uuid.UUID    `json:"id" db:"id"`
+	UserID    uuid.UUID    `json:"user_id" db:"user_id"`
+	Purpose   TokenPurpose `json:"purpose" db:"purpose"`
+	TokenHash string       `json:"-" db:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops buckets that have refilled
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Limits aren't shared between
+// instances, so each replica allows the full rate on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket will have refilled, after which it's no
+	// different from a missing one and can be dropped
+	full time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Take counts a request against key's bucket
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Limit), updated: now}
+		s.buckets[key] = b
+	}
+
+	tokens, result := take(policy, b.tokens, b.updated, now)
+	b.tokens = tokens
+	b.updated = now
+	b.full = now.Add(result.ResetAfter)
+
+	return result, nil
+}
+
+// sweep drops full buckets so memory tracks active clients only
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,89 @@
+// Package ratelimit implements token-bucket rate limiting behind a pluggable
+// store, so limits can be kept in process or shared between instances.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a token bucket holding up to Limit requests that refills at
+// Limit per Window. A client may burst up to Limit requests and then
+// continues at the refill rate.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// ParsePolicy reads a policy written as "<limit>/<window>", e.g. "10/1m"
+func ParsePolicy(name, spec string) (Policy, error) {
+	limit, window, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid %s rate limit %q: want <limit>/<window>, e.g. 10/1m", name, spec)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || n <= 0 {
+		return Policy{}, fmt.Errorf("invalid %s rate limit %q: limit must be a positive integer", name, spec)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d <= 0 {
+		return Policy{}, fmt.Errorf("invalid %s rate limit %q: window must be a positive duration", name, spec)
+	}
+
+	return Policy{Name: name, Limit: n, Window: d}, nil
+}
+
+// rate is the refill rate in tokens per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// Result describes the caller's bucket after a request was counted
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request would be allowed; zero when allowed
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+}
+
+// Store keeps buckets by key. Take must be atomic per key so concurrent
+// requests can't spend the same token, which for a shared store means doing
+// the refill and spend in a single round trip.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// take refills a bucket holding tokens as of updated and tries to spend one.
+// It returns the bucket's new token count along with the result.
+func take(policy Policy, tokens float64, updated, now time.Time) (float64, Result) {
+	rate := policy.rate()
+	limit := float64(policy.Limit)
+
+	tokens = math.Min(limit, tokens+now.Sub(updated).Seconds()*rate)
+
+	result := Result{Limit: policy.Limit}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.ResetAfter = seconds((limit - tokens) / rate)
+
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Policy
+		wantErr bool
+	}{
+		{"10/1m", Policy{Name: "read", Limit: 10, Window: time.Minute}, false},
+		{" 5 / 30s ", Policy{Name: "read", Limit: 5, Window: 30 * time.Second}, false},
+		{"10", Policy{}, true},
+		{"0/1m", Policy{}, true},
+		{"-1/1m", Policy{}, true},
+		{"ten/1m", Policy{}, true},
+		{"10/0s", Policy{}, true},
+		{"10/soon", Policy{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePolicy("read", tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePolicy(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	// 10 per 10s refills one token a second
+	policy := Policy{Name: "test", Limit: 10, Window: 10 * time.Second}
+	start := time.Unix(1000, 0)
+
+	tests := []struct {
+		name          string
+		tokens        float64
+		elapsed       time.Duration
+		wantTokens    float64
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+		wantReset     time.Duration
+	}{
+		{"full bucket", 10, 0, 9, true, 9, 0, time.Second},
+		{"last token", 1, 0, 0, true, 0, 0, 10 * time.Second},
+		{"empty bucket", 0, 0, 0, false, 0, time.Second, 10 * time.Second},
+		{"partly refilled", 0, 500 * time.Millisecond, 0.5, false, 0, 500 * time.Millisecond, 9500 * time.Millisecond},
+		{"refilled one", 0, time.Second, 0, true, 0, 0, 10 * time.Second},
+		{"refill capped at limit", 5, time.Hour, 9, true, 9, 0, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, result := take(policy, tt.tokens, start, start.Add(tt.elapsed))
+			if tokens != tt.wantTokens {
+				t.Errorf("tokens = %v, want %v", tokens, tt.wantTokens)
+			}
+			if result.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v", result.Allowed, tt.wantAllowed)
+			}
+			if result.Limit != policy.Limit {
+				t.Errorf("Limit = %d, want %d", result.Limit, policy.Limit)
+			}
+			if result.Remaining != tt.wantRemaining {
+				t.Errorf("Remaining = %d, want %d", result.Remaining, tt.wantRemaining)
+			}
+			if result.RetryAfter != tt.wantRetry {
+				t.Errorf("RetryAfter = %v, want %v", result.RetryAfter, tt.wantRetry)
+			}
+			if result.ResetAfter != tt.wantReset {
+				t.Errorf("ResetAfter = %v, want %v", result.ResetAfter, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreBurstThenRefuse(t *testing.T) {
+	store := NewMemoryStore()
+	// A window long enough that no token refills during the test
+	policy := Policy{Name: "test", Limit: 3, Window: time.Hour}
+	ctx := context.Background()
+
+	for i := 0; i < policy.Limit; i++ {
+		result, err := store.Take(ctx, "client", policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed {
+			t.Fatalf("request %d refused within the burst", i+1)
+		}
+		if want := policy.Limit - i - 1; result.Remaining != want {
+			t.Errorf("request %d: Remaining = %d, want %d", i+1, result.Remaining, want)
+		}
+	}
+
+	result, err := store.Take(ctx, "client", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed {
+		t.Error("request over the burst allowed")
+	}
+	if result.RetryAfter <= 0 {
+		t.Errorf("RetryAfter = %v, want positive", result.RetryAfter)
+	}
+
+	// Other keys have their own bucket
+	result, err = store.Take(ctx, "other", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed {
+		t.Error("another key's first request refused")
+	}
+}
+
+func TestMemoryStoreSweepKeepsPartialBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	policy := Policy{Name: "test", Limit: 2, Window: time.Hour}
+	ctx := context.Background()
+
+	if _, err := store.Take(ctx, "client", policy); err != nil {
+		t.Fatal(err)
+	}
+	store.buckets["idle"] = &bucket{tokens: 2, full: time.Now().Add(-time.Second)}
+
+	store.sweep(time.Now())
+
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("sweep kept a full bucket")
+	}
+	if _, ok := store.buckets["client"]; !ok {
+		t.Error("sweep dropped a bucket that is still refilling")
+	}
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+// ErrCommentNotFound is returned when a comment does not exist
+var ErrCommentNotFound = apperr.NotFound("comment_not_found", "comment not found")
+
+type commentRepository struct {
+	db *sql.DB
+}
+
+// NewCommentRepository creates a new comment repository
+func NewCommentRepository(db *sql.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// commentColumns selects a comment joined with its author
+const commentColumns = `
+	c.id, c.post_id, c.user_id, c.content, c.created_at, c.updated_at,
+	u.id, u.username, u.full_name, u.bio, u.avatar, u.created_at`
+
+// scanComment scans a row selected with commentColumns
+func scanComment(row interface{ Scan(...interface{}) error }) (*model.Comment, error) {
+	comment := &model.Comment{Author: &model.UserResponse{}}
+	err := row.Scan(
+		&comment.ID, &comment.PostID, &comment.UserID, &comment.Content, &comment.CreatedAt, &comment.UpdatedAt,
+		&comment.Author.ID, &comment.Author.Username, &comment.Author.FullName, &comment.Author.Bio, &comment.Author.Avatar, &comment.Author.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// Create inserts a new comment into the database
+func (r *commentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	query := `
+		INSERT INTO comments (id, post_id, user_id, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	now := time.Now()
+	comment.ID = uuid.New()
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+
+	_, err := r.db.ExecContext(ctx, query,
+		comment.ID, comment.PostID, comment.UserID, comment.Content, comment.CreatedAt, comment.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create comment: %w", wrapPgError(err))
+	}
+
+	return nil
+}
+
+// GetByID retrieves a single comment with its author
+func (r *commentRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+	query := `
+		SELECT` + commentColumns + `
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1`
+
+	comment, err := scanComment(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to get comment by ID: %w", err)
+	}
+
+	return comment, nil
+}
+
+// GetByPostID retrieves a page of a post's comments, oldest first, skipping banned authors
+func (r *commentRepository) GetByPostID(ctx context.Context, postID uuid.UUID, page model.PageRequest) ([]*model.Comment, *model.Cursor, error) {
+	query := `
+		SELECT` + commentColumns + `
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.post_id = $1 AND u.banned_at IS NULL
+		  AND ($2::timestamptz IS NULL OR (c.created_at, c.id) > ($2, $3::uuid))
+		ORDER BY c.created_at ASC, c.id ASC
+		LIMIT $4`
+
+	after, afterID := cursorArgs(page)
+	rows, err := r.db.QueryContext(ctx, query, postID, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]*model.Comment, 0, page.Limit+1)
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate comments: %w", err)
+	}
+
+	comments, next := nextCursor(comments, page.Limit, func(c *model.Comment) (time.Time, uuid.UUID) {
+		return c.CreatedAt, c.ID
+	})
+	return comments, next, nil
+}
+
+// Delete removes a comment from the database
+func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM comments WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
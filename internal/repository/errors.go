@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+)
+
+// Postgres error codes we translate into domain errors
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+var (
+	// ErrEmailTaken is returned when another account already uses the email address
+	ErrEmailTaken = apperr.Conflict("email_taken", "email is already registered")
+	// ErrUsernameTaken is returned when another account already uses the username
+	ErrUsernameTaken = apperr.Conflict("username_taken", "username is already taken")
+	// ErrDuplicate is returned for a unique violation with no more specific error
+	ErrDuplicate = apperr.Conflict("duplicate", "resource already exists")
+	// ErrReferenceNotFound is returned for a foreign key violation with no more specific error
+	ErrReferenceNotFound = apperr.NotFound("reference_not_found", "referenced resource does not exist")
+)
+
+// constraintErrors maps constraints to the domain error a violation of them means
+var constraintErrors = map[string]error{
+	"users_email_key":       ErrEmailTaken,
+	"users_username_key":    ErrUsernameTaken,
+	"posts_user_id_fkey":    ErrUserNotFound,
+	"comments_post_id_fkey": ErrPostNotFound,
+	"likes_post_id_fkey":    ErrPostNotFound,
+}
+
+// isPgError reports whether err is a Postgres error with the given SQLSTATE code
+func isPgError(err error, code string) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code) == code
+	}
+	return false
+}
+
+// wrapPgError wraps unique and foreign key violations in the matching domain
+// error, keeping the Postgres error as the cause. Other errors are returned
+// unchanged.
+func wrapPgError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	if domainErr, ok := constraintErrors[pqErr.Constraint]; ok {
+		return fmt.Errorf("%w: %w", domainErr, err)
+	}
+
+	switch string(pqErr.Code) {
+	case pgUniqueViolation:
+		return fmt.Errorf("%w: %w", ErrDuplicate, err)
+	case pgForeignKeyViolation:
+		return fmt.Errorf("%w: %w", ErrReferenceNotFound, err)
+	}
+	return err
+}
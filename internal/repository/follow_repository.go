@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+// ErrSelfFollow is returned when a user tries to follow themselves
+var ErrSelfFollow = apperr.Validation("cannot_follow_self", "users cannot follow themselves")
+
+type followRepository struct {
+	db *sql.DB
+}
+
+// NewFollowRepository creates a new follow repository
+func NewFollowRepository(db *sql.DB) FollowRepository {
+	return &followRepository{db: db}
+}
+
+// Follow records that followerID follows followedID. Following twice is a
+// no-op, reported by created being false.
+func (r *followRepository) Follow(ctx context.Context, followerID, followedID uuid.UUID) (bool, error) {
+	query := `
+		INSERT INTO follows (id, follower_id, followed_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (follower_id, followed_id) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query, uuid.New(), followerID, followedID, time.Now())
+	if err != nil {
+		switch {
+		case isPgError(err, pgCheckViolation):
+			return false, ErrSelfFollow
+		case isPgError(err, pgForeignKeyViolation):
+			return false, ErrUserNotFound
+		}
+		return false, fmt.Errorf("failed to follow user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Unfollow removes the follow relationship. Unfollowing twice is a no-op.
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followedID uuid.UUID) error {
+	query := `DELETE FROM follows WHERE follower_id = $1 AND followed_id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, followerID, followedID); err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+
+	return nil
+}
+
+// IsFollowing reports whether followerID follows followedID
+func (r *followRepository) IsFollowing(ctx context.Context, followerID, followedID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND followed_id = $2)`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, followerID, followedID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check follow: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetFollowers retrieves a page of the users following userID, most recent first, skipping banned users
+func (r *followRepository) GetFollowers(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.User, *model.Cursor, error) {
+	query := `
+		SELECT f.id, f.created_at, u.id, u.username, u.full_name, u.bio, u.avatar, u.created_at
+		FROM follows f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followed_id = $1 AND u.banned_at IS NULL
+		  AND ($2::timestamptz IS NULL OR (f.created_at, f.id) < ($2, $3::uuid))
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT $4`
+
+	return r.queryUserPage(ctx, query, userID, page)
+}
+
+// GetFollowing retrieves a page of the users userID follows, most recent first, skipping banned users
+func (r *followRepository) GetFollowing(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.User, *model.Cursor, error) {
+	query := `
+		SELECT f.id, f.created_at, u.id, u.username, u.full_name, u.bio, u.avatar, u.created_at
+		FROM follows f
+		JOIN users u ON u.id = f.followed_id
+		WHERE f.follower_id = $1 AND u.banned_at IS NULL
+		  AND ($2::timestamptz IS NULL OR (f.created_at, f.id) < ($2, $3::uuid))
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT $4`
+
+	return r.queryUserPage(ctx, query, userID, page)
+}
+
+// CountFollows returns how many users follow userID and how many userID
+// follows, leaving out banned users as the follow lists do
+func (r *followRepository) CountFollows(ctx context.Context, userID uuid.UUID) (int, int, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM follows f JOIN users u ON u.id = f.follower_id
+			 WHERE f.followed_id = $1 AND u.banned_at IS NULL),
+			(SELECT COUNT(*) FROM follows f JOIN users u ON u.id = f.followed_id
+			 WHERE f.follower_id = $1 AND u.banned_at IS NULL)`
+
+	var followers, following int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&followers, &following); err != nil {
+		return 0, 0, fmt.Errorf("failed to count follows: %w", err)
+	}
+
+	return followers, following, nil
+}
+
+// queryUserPage runs a follow list query and scans the public user columns.
+// The page is keyed on the follow row, so the cursor marks when the follow happened.
+func (r *followRepository) queryUserPage(ctx context.Context, query string, userID uuid.UUID, page model.PageRequest) ([]*model.User, *model.Cursor, error) {
+	after, afterID := cursorArgs(page)
+	rows, err := r.db.QueryContext(ctx, query, userID, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query follows: %w", err)
+	}
+	defer rows.Close()
+
+	type followedUser struct {
+		user     *model.User
+		followID uuid.UUID
+		followAt time.Time
+	}
+
+	results := make([]followedUser, 0, page.Limit+1)
+	for rows.Next() {
+		row := followedUser{user: &model.User{}}
+		if err := rows.Scan(&row.followID, &row.followAt,
+			&row.user.ID, &row.user.Username, &row.user.FullName, &row.user.Bio, &row.user.Avatar, &row.user.CreatedAt,
+		); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate follows: %w", err)
+	}
+
+	results, next := nextCursor(results, page.Limit, func(f followedUser) (time.Time, uuid.UUID) {
+		return f.followAt, f.followID
+	})
+
+	users := make([]*model.User, 0, len(results))
+	for _, row := range results {
+		users = append(users, row.user)
+	}
+	return users, next, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/naval1525/Social_Media_Backend/internal/model"
@@ -13,37 +14,100 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
-	Update(ctx context.Context, user *model.User) error
+	List(ctx context.Context, page model.PageRequest) ([]*model.User, *model.Cursor, error)
+	Update(ctx context.Context, user *model.User, ifUpdatedAt *time.Time) error
+	ChangeUsername(ctx context.Context, id uuid.UUID, username string, holdUntil time.Time) error
+	UpdateRole(ctx context.Context, id uuid.UUID, role model.Role) error
+	MarkEmailVerified(ctx context.Context, id uuid.UUID) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	SetPendingTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, id uuid.UUID, step int64) error
+	DisableTOTP(ctx context.Context, id uuid.UUID) error
+	RecordTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
+	RecordLoginFailure(ctx context.Context, id uuid.UUID, at time.Time, threshold int, lockUntil time.Time) (bool, error)
+	ResetLoginFailures(ctx context.Context, id uuid.UUID) error
+	ScheduleDeletion(ctx context.Context, id uuid.UUID, at time.Time) error
+	CancelDeletion(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+	DeleteScheduled(ctx context.Context, now time.Time) (int64, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// List methods use keyset pagination on (created_at, id): they return at most
+// page.Limit items plus the cursor of the next page, or nil on the last page.
+
+// PostRepository reads posts on behalf of a viewer so IsLiked can be filled in;
+// pass uuid.Nil for anonymous viewers. Like counts are maintained by LikeRepository.
 type PostRepository interface {
 	Create(ctx context.Context, post *model.Post) error
-	GetById(ctx context.Context, id uuid.UUID) ([]*model.Post, error)
-	GetByUserId(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*model.Post, error)
-	GetFeed(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*model.Post, error)
+	GetById(ctx context.Context, id, viewerID uuid.UUID) (*model.Post, error)
+	GetByUserId(ctx context.Context, userID, viewerID uuid.UUID, page model.PageRequest) ([]*model.Post, *model.Cursor, error)
+	GetFeed(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.Post, *model.Cursor, error)
+	CountByUser(ctx context.Context, userID uuid.UUID) (int, error)
 	Update(ctx context.Context, post *model.Post) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	IncrementLikeCount(ctx context.Context, postid uuid.UUID) error
-	DecrementLikeCount(ctx context.Context, postid uuid.UUID) error
 }
 
 type CommentRepository interface {
 	Create(ctx context.Context, comment *model.Comment) error
-	GetByPostID(ctx context.Context, postID uuid.UUID, limit, offset int) ([]*model.Comment, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Comment, error)
+	GetByPostID(ctx context.Context, postID uuid.UUID, page model.PageRequest) ([]*model.Comment, *model.Cursor, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type FollowRepository interface {
-	Follow(ctx context.Context, followerID, followingID uuid.UUID) error
-	Unfollow(ctx context.Context, followerID, followingID uuid.UUID) error
-	IsFollowing(ctx context.Context, followerID, followingID uuid.UUID) (bool, error)
-	GetFollowers(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*model.User, error)
-	GetFollowing(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*model.User, error)
+	Follow(ctx context.Context, followerID, followedID uuid.UUID) (created bool, err error)
+	Unfollow(ctx context.Context, followerID, followedID uuid.UUID) error
+	IsFollowing(ctx context.Context, followerID, followedID uuid.UUID) (bool, error)
+	GetFollowers(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.User, *model.Cursor, error)
+	GetFollowing(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.User, *model.Cursor, error)
+	CountFollows(ctx context.Context, userID uuid.UUID) (followers, following int, err error)
 }
 
+// LikeRepository writes the like row and posts.like_count in one transaction.
+// Like and Unlike are idempotent and return the post's resulting like count;
+// Like also reports whether the like is new.
 type LikeRepository interface {
-	Like(ctx context.Context, userID, postID uuid.UUID) error
-	Unlike(ctx context.Context, userID, postID uuid.UUID) error
+	Like(ctx context.Context, userID, postID uuid.UUID) (count int, created bool, err error)
+	Unlike(ctx context.Context, userID, postID uuid.UUID) (int, error)
 	IsLiked(ctx context.Context, userID, postID uuid.UUID) (bool, error)
 }
+
+// SessionRepository stores refresh tokens by their SHA-256 hash
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	Rotate(ctx context.Context, currentID uuid.UUID, next *model.Session) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+	IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, error)
+}
+
+// UserTokenRepository stores single-use, expiring tokens (email verification,
+// password reset) by their SHA-256 hash
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *model.UserToken) error
+	Consume(ctx context.Context, purpose model.TokenPurpose, tokenHash string) (*model.UserToken, error)
+	DeleteForUser(ctx context.Context, userID uuid.UUID, purpose model.TokenPurpose) error
+	DeleteStale(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// ModerationRepository records suspensions and bans along with the user state they change
+type ModerationRepository interface {
+	Apply(ctx context.Context, action *model.ModerationAction) error
+	ListForUser(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.ModerationAction, *model.Cursor, error)
+}
+
+// RecoveryCodeRepository stores two-factor recovery codes by their SHA-256 hash
+type RecoveryCodeRepository interface {
+	ReplaceForUser(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	Consume(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	DeleteForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// LoginAttemptRepository stores the login history used for brute-force
+// protection and shown to users
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt *model.LoginAttempt) error
+	CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int, time.Time, error)
+	ListForUser(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.LoginAttempt, *model.Cursor, error)
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type likeRepository struct {
+	db *sql.DB
+}
+
+// NewLikeRepository creates a new like repository
+func NewLikeRepository(db *sql.DB) LikeRepository {
+	return &likeRepository{db: db}
+}
+
+// Like records a like and bumps posts.like_count in the same transaction.
+// Liking an already liked post leaves the count untouched and reports created
+// as false.
+func (r *likeRepository) Like(ctx context.Context, userID, postID uuid.UUID) (int, bool, error) {
+	insert := `
+		INSERT INTO likes (id, user_id, post_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, post_id) DO NOTHING`
+
+	return r.withLikeTx(ctx, postID, 1, func(tx *sql.Tx) (sql.Result, error) {
+		result, err := tx.ExecContext(ctx, insert, uuid.New(), userID, postID, time.Now())
+		if isPgError(err, pgForeignKeyViolation) {
+			return nil, ErrPostNotFound
+		}
+		return result, err
+	})
+}
+
+// Unlike removes a like and decrements posts.like_count in the same transaction.
+// Unliking a post that isn't liked leaves the count untouched.
+func (r *likeRepository) Unlike(ctx context.Context, userID, postID uuid.UUID) (int, error) {
+	del := `DELETE FROM likes WHERE user_id = $1 AND post_id = $2`
+
+	count, _, err := r.withLikeTx(ctx, postID, -1, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, del, userID, postID)
+	})
+	return count, err
+}
+
+// IsLiked reports whether the user has liked the post
+func (r *likeRepository) IsLiked(ctx context.Context, userID, postID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM likes WHERE user_id = $1 AND post_id = $2)`
+
+	var liked bool
+	if err := r.db.QueryRowContext(ctx, query, userID, postID).Scan(&liked); err != nil {
+		return false, fmt.Errorf("failed to check like: %w", err)
+	}
+
+	return liked, nil
+}
+
+// withLikeTx runs write inside a transaction and, only if it changed a row,
+// applies delta to the post's like_count. It returns the resulting count and
+// whether a row changed.
+func (r *likeRepository) withLikeTx(ctx context.Context, postID uuid.UUID, delta int, write func(tx *sql.Tx) (sql.Result, error)) (int, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the post row first so concurrent likes on the same post serialize
+	// and a missing post is reported before touching the likes table. Posts by
+	// banned users are hidden, so they count as missing too.
+	lock := `
+		SELECT p.like_count
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1 AND u.banned_at IS NULL
+		FOR UPDATE OF p`
+
+	var likeCount int
+	err = tx.QueryRowContext(ctx, lock, postID).Scan(&likeCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, ErrPostNotFound
+		}
+		return 0, false, fmt.Errorf("failed to lock post: %w", err)
+	}
+
+	result, err := write(tx)
+	if err != nil {
+		if err == ErrPostNotFound {
+			return 0, false, err
+		}
+		return 0, false, fmt.Errorf("failed to write like: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		query := `UPDATE posts SET like_count = GREATEST(like_count + $2, 0) WHERE id = $1 RETURNING like_count`
+		if err := tx.QueryRowContext(ctx, query, postID, delta).Scan(&likeCount); err != nil {
+			return 0, false, fmt.Errorf("failed to update like count: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit like: %w", err)
+	}
+
+	return likeCount, rowsAffected > 0, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+type loginAttemptRepository struct {
+	db *sql.DB
+}
+
+// NewLoginAttemptRepository creates a new login attempt repository
+func NewLoginAttemptRepository(db *sql.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// Create records a login attempt
+func (r *loginAttemptRepository) Create(ctx context.Context, attempt *model.LoginAttempt) error {
+	query := `
+		INSERT INTO login_attempts (id, user_id, ip_address, user_agent, success, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	attempt.ID = uuid.New()
+	attempt.CreatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, query,
+		attempt.ID, attempt.UserID, attempt.IPAddress, attempt.UserAgent, attempt.Success, attempt.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", wrapPgError(err))
+	}
+
+	return nil
+}
+
+// CountFailuresByIP counts failed logins from ip since the given time, along
+// with when the oldest of them happened
+func (r *loginAttemptRepository) CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int, time.Time, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(MIN(created_at), $2)
+		FROM login_attempts
+		WHERE ip_address = $1 AND NOT success AND created_at > $2`
+
+	var count int
+	var oldest time.Time
+	if err := r.db.QueryRowContext(ctx, query, ip, since).Scan(&count, &oldest); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to count login failures: %w", err)
+	}
+
+	return count, oldest, nil
+}
+
+// ListForUser retrieves a page of the user's login attempts, newest first
+func (r *loginAttemptRepository) ListForUser(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.LoginAttempt, *model.Cursor, error) {
+	query := `
+		SELECT id, user_id, ip_address, user_agent, success, created_at
+		FROM login_attempts
+		WHERE user_id = $1
+		  AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4`
+
+	after, afterID := cursorArgs(page)
+	rows, err := r.db.QueryContext(ctx, query, userID, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query login attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := make([]*model.LoginAttempt, 0, page.Limit+1)
+	for rows.Next() {
+		attempt := &model.LoginAttempt{}
+		if err := rows.Scan(
+			&attempt.ID, &attempt.UserID, &attempt.IPAddress, &attempt.UserAgent, &attempt.Success, &attempt.CreatedAt,
+		); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan login attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate login attempts: %w", err)
+	}
+
+	attempts, next := nextCursor(attempts, page.Limit, func(a *model.LoginAttempt) (time.Time, uuid.UUID) {
+		return a.CreatedAt, a.ID
+	})
+	return attempts, next, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+type moderationRepository struct {
+	db *sql.DB
+}
+
+// NewModerationRepository creates a new moderation repository
+func NewModerationRepository(db *sql.DB) ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+// Apply updates the user's restriction state and records the action in one
+// transaction. Bans are stamped with the action time; suspensions end at ExpiresAt.
+func (r *moderationRepository) Apply(ctx context.Context, action *model.ModerationAction) error {
+	action.ID = uuid.New()
+	action.CreatedAt = time.Now()
+
+	var update string
+	args := []interface{}{action.UserID}
+	switch action.Action {
+	case model.ModerationSuspend:
+		update = `UPDATE users SET suspended_until = $2 WHERE id = $1`
+		args = append(args, action.ExpiresAt)
+	case model.ModerationUnsuspend:
+		update = `UPDATE users SET suspended_until = NULL WHERE id = $1`
+	case model.ModerationBan:
+		update = `UPDATE users SET banned_at = $2 WHERE id = $1`
+		args = append(args, action.CreatedAt)
+	case model.ModerationUnban:
+		update = `UPDATE users SET banned_at = NULL WHERE id = $1`
+	default:
+		return fmt.Errorf("unknown moderation action %q", action.Action)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, update, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update user restrictions: %w", err)
+	}
+	if err := checkUserAffected(result); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO moderation_actions (id, user_id, actor_id, action, reason, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := tx.ExecContext(ctx, query,
+		action.ID, action.UserID, action.ActorID, action.Action, action.Reason, action.ExpiresAt, action.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to record moderation action: %w", wrapPgError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit moderation action: %w", err)
+	}
+
+	return nil
+}
+
+// ListForUser retrieves a page of the actions taken on a user, newest first
+func (r *moderationRepository) ListForUser(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.ModerationAction, *model.Cursor, error) {
+	query := `
+		SELECT id, user_id, actor_id, action, reason, expires_at, created_at
+		FROM moderation_actions
+		WHERE user_id = $1
+		  AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4`
+
+	after, afterID := cursorArgs(page)
+	rows, err := r.db.QueryContext(ctx, query, userID, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query moderation actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := make([]*model.ModerationAction, 0, page.Limit+1)
+	for rows.Next() {
+		action := &model.ModerationAction{}
+		if err := rows.Scan(
+			&action.ID, &action.UserID, &action.ActorID, &action.Action, &action.Reason, &action.ExpiresAt, &action.CreatedAt,
+		); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan moderation action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate moderation actions: %w", err)
+	}
+
+	actions, next := nextCursor(actions, page.Limit, func(a *model.ModerationAction) (time.Time, uuid.UUID) {
+		return a.CreatedAt, a.ID
+	})
+	return actions, next, nil
+}
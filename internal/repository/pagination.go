@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+// cursorArgs returns the (created_at, id) query arguments for a keyset filter
+// written as `($n::timestamptz IS NULL OR (t.created_at, t.id) < ($n, $n+1::uuid))`.
+// Both are nil when the page starts at the beginning of the list.
+func cursorArgs(page model.PageRequest) (interface{}, interface{}) {
+	if page.After == nil {
+		return nil, nil
+	}
+	return page.After.CreatedAt, page.After.ID
+}
+
+// nextCursor trims the extra row fetched beyond the page limit and, when there
+// was one, returns the cursor of the last row kept so the next page starts after it.
+func nextCursor[T any](items []T, limit int, key func(T) (time.Time, uuid.UUID)) ([]T, *model.Cursor) {
+	if len(items) <= limit {
+		return items, nil
+	}
+
+	items = items[:limit]
+	createdAt, id := key(items[limit-1])
+	return items, &model.Cursor{CreatedAt: createdAt, ID: id}
+}
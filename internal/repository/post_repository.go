@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+// ErrPostNotFound is returned when a post does not exist
+var ErrPostNotFound = apperr.NotFound("post_not_found", "post not found")
+
+type postRepository struct {
+	db *sql.DB
+}
+
+// NewPostRepository creates a new post repository
+func NewPostRepository(db *sql.DB) PostRepository {
+	return &postRepository{db: db}
+}
+
+// postColumns selects a post joined with its author. The viewer's ID must be
+// bound as $1 so is_liked can be computed in the same query. Every read also
+// filters on u.banned_at IS NULL so banned users' posts stay hidden, and
+// comment_count leaves out their comments.
+const postColumns = `
+	p.id, p.user_id, p.content, p.image_url, p.like_count, p.created_at, p.updated_at,
+	(SELECT COUNT(*) FROM comments c JOIN users cu ON cu.id = c.user_id
+	 WHERE c.post_id = p.id AND cu.banned_at IS NULL) AS comment_count,
+	EXISTS(SELECT 1 FROM likes l WHERE l.post_id = p.id AND l.user_id = $1) AS is_liked,
+	u.id, u.username, u.full_name, u.bio, u.avatar, u.created_at`
+
+// scanPost scans a row selected with postColumns
+func scanPost(row interface{ Scan(...interface{}) error }) (*model.Post, error) {
+	post := &model.Post{Author: &model.UserResponse{}}
+	err := row.Scan(
+		&post.ID, &post.UserID, &post.Content, &post.ImageURL, &post.LikeCount, &post.CreatedAt, &post.UpdatedAt,
+		&post.CommentCount, &post.IsLiked,
+		&post.Author.ID, &post.Author.Username, &post.Author.FullName, &post.Author.Bio, &post.Author.Avatar, &post.Author.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// Create inserts a new post into the database
+func (r *postRepository) Create(ctx context.Context, post *model.Post) error {
+	query := `
+		INSERT INTO posts (id, user_id, content, image_url, like_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 0, $5, $6)`
+
+	now := time.Now()
+	post.ID = uuid.New()
+	post.LikeCount = 0
+	post.CreatedAt = now
+	post.UpdatedAt = now
+
+	_, err := r.db.ExecContext(ctx, query,
+		post.ID, post.UserID, post.Content, post.ImageURL, post.CreatedAt, post.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create post: %w", wrapPgError(err))
+	}
+
+	return nil
+}
+
+// GetById retrieves a single post with its author
+func (r *postRepository) GetById(ctx context.Context, id, viewerID uuid.UUID) (*model.Post, error) {
+	query := `
+		SELECT` + postColumns + `
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $2 AND u.banned_at IS NULL`
+
+	post, err := scanPost(r.db.QueryRowContext(ctx, query, viewerID, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPostNotFound
+		}
+		return nil, fmt.Errorf("failed to get post by ID: %w", err)
+	}
+
+	return post, nil
+}
+
+// GetByUserId retrieves a page of a user's posts, newest first
+func (r *postRepository) GetByUserId(ctx context.Context, userID, viewerID uuid.UUID, page model.PageRequest) ([]*model.Post, *model.Cursor, error) {
+	query := `
+		SELECT` + postColumns + `
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.user_id = $2 AND u.banned_at IS NULL
+		  AND ($3::timestamptz IS NULL OR (p.created_at, p.id) < ($3, $4::uuid))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $5`
+
+	after, afterID := cursorArgs(page)
+	return r.queryPostPage(ctx, page.Limit, query, viewerID, userID, after, afterID, page.Limit+1)
+}
+
+// GetFeed retrieves a page of posts from the user and the accounts they follow,
+// newest first. Authors come from idx_follows_follower_id. For each one a
+// lateral subquery walks idx_posts_user_id_created_at_id from the cursor and
+// stops after a page of posts, so at most a page per author is sorted rather
+// than every post they have written. Author and is_liked come from the same
+// query.
+func (r *postRepository) GetFeed(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.Post, *model.Cursor, error) {
+	query := `
+		WITH authors AS (
+			SELECT followed_id AS user_id FROM follows WHERE follower_id = $1
+			UNION ALL
+			SELECT $1::uuid
+		)
+		SELECT` + postColumns + `
+		FROM authors a
+		JOIN users u ON u.id = a.user_id AND u.banned_at IS NULL
+		CROSS JOIN LATERAL (
+			SELECT id, user_id, content, image_url, like_count, created_at, updated_at
+			FROM posts
+			WHERE user_id = a.user_id
+			  AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+			ORDER BY created_at DESC, id DESC
+			LIMIT $4
+		) p
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $4`
+
+	after, afterID := cursorArgs(page)
+	return r.queryPostPage(ctx, page.Limit, query, userID, after, afterID, page.Limit+1)
+}
+
+// CountByUser counts the posts a user has published
+func (r *postRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM posts WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	return count, nil
+}
+
+// Update updates a post's content
+func (r *postRepository) Update(ctx context.Context, post *model.Post) error {
+	query := `
+		UPDATE posts
+		SET content = $2, image_url = $3, updated_at = $4
+		WHERE id = $1`
+
+	post.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, post.ID, post.Content, post.ImageURL, post.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update post: %w", err)
+	}
+
+	return checkPostAffected(result)
+}
+
+// Delete removes a post from the database
+func (r *postRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM posts WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete post: %w", err)
+	}
+
+	return checkPostAffected(result)
+}
+
+// queryPostPage runs a query selecting postColumns that fetches one row beyond
+// limit, and returns the page along with the cursor for the next one
+func (r *postRepository) queryPostPage(ctx context.Context, limit int, query string, args ...interface{}) ([]*model.Post, *model.Cursor, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := make([]*model.Post, 0, limit+1)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate posts: %w", err)
+	}
+
+	posts, next := nextCursor(posts, limit, func(p *model.Post) (time.Time, uuid.UUID) {
+		return p.CreatedAt, p.ID
+	})
+	return posts, next, nil
+}
+
+// checkPostAffected maps a zero-row write to ErrPostNotFound
+func checkPostAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrPostNotFound
+	}
+
+	return nil
+}
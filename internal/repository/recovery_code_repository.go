@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type recoveryCodeRepository struct {
+	db *sql.DB
+}
+
+// NewRecoveryCodeRepository creates a new recovery code repository
+func NewRecoveryCodeRepository(db *sql.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser discards the user's existing codes and stores a new set in one transaction
+func (r *recoveryCodeRepository) ReplaceForUser(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	query := `
+		INSERT INTO recovery_codes (id, user_id, code_hash, created_at)
+		VALUES ($1, $2, $3, $4)`
+
+	now := time.Now()
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, query, uuid.New(), userID, hash, now); err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", wrapPgError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marks an unused code as used. It returns false if no such unused code exists.
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE recovery_codes SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteForUser removes all of the user's recovery codes
+func (r *recoveryCodeRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+var (
+	// ErrSessionNotFound is returned when no session matches a refresh token
+	ErrSessionNotFound = apperr.NotFound("session_not_found", "session not found")
+	// ErrSessionInactive is returned when rotating a session that was already rotated or revoked
+	ErrSessionInactive = apperr.Conflict("session_inactive", "session is no longer active")
+)
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create inserts a new session into the database
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	return insertSession(ctx, r.db, session)
+}
+
+// GetByTokenHash retrieves a session by the hash of its refresh token
+func (r *sessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, user_agent, ip_address, expires_at, rotated_at, revoked_at, created_at
+		FROM sessions WHERE token_hash = $1`
+
+	session := &model.Session{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&session.ID, &session.UserID, &session.FamilyID, &session.TokenHash, &session.UserAgent,
+		&session.IPAddress, &session.ExpiresAt, &session.RotatedAt, &session.RevokedAt, &session.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Rotate marks the current session as rotated and inserts its successor in one
+// transaction. Only one concurrent rotation of the same session can succeed;
+// the others get ErrSessionInactive.
+func (r *sessionRepository) Rotate(ctx context.Context, currentID uuid.UUID, next *model.Session) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE sessions SET rotated_at = $2
+		WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL`
+
+	result, err := tx.ExecContext(ctx, query, currentID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrSessionInactive
+	}
+
+	if err := insertSession(ctx, tx, next); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit session rotation: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every refresh token descended from the same login
+func (r *sessionRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `UPDATE sessions SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, familyID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke session family: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes every session belonging to the user
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE sessions SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+// IsFamilyActive reports whether the family still has a usable refresh token,
+// i.e. the user hasn't logged out and the family wasn't revoked for reuse
+func (r *sessionRepository) IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM sessions
+			WHERE family_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		)`
+
+	var active bool
+	if err := r.db.QueryRowContext(ctx, query, familyID).Scan(&active); err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return active, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertSession writes a session row using db or an open transaction
+func insertSession(ctx context.Context, db execer, session *model.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, family_id, token_hash, user_agent, ip_address, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	session.ID = uuid.New()
+	session.CreatedAt = time.Now()
+
+	_, err := db.ExecContext(ctx, query,
+		session.ID, session.UserID, session.FamilyID, session.TokenHash, session.UserAgent,
+		session.IPAddress, session.ExpiresAt, session.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", wrapPgError(err))
+	}
+
+	return nil
+}
@@ -8,9 +8,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
 	"github.com/naval1525/Social_Media_Backend/internal/model"
 )
 
+var (
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = apperr.NotFound("user_not_found", "user not found")
+	// ErrUserModified is returned when a conditional update finds the user changed since it was read
+	ErrUserModified = apperr.PreconditionFailed("user_modified", "profile has changed since it was fetched; fetch it again and retry")
+)
+
 type userRepository struct {
 	db *sql.DB
 }
@@ -20,6 +28,26 @@ func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// userColumns lists every users column scanned by scanUser
+const userColumns = `
+	id, username, email, password_hash, full_name, bio, avatar,
+	email_verified_at, COALESCE(totp_secret, ''), totp_enabled_at, role, suspended_until, banned_at,
+	failed_login_attempts, last_failed_login_at, locked_until, username_changed_at, deletion_scheduled_at, created_at, updated_at`
+
+// scanUser scans a row selected with userColumns
+func scanUser(row interface{ Scan(...interface{}) error }) (*model.User, error) {
+	user := &model.User{}
+	err := row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.Password, &user.FullName, &user.Bio, &user.Avatar,
+		&user.EmailVerifiedAt, &user.TOTPSecret, &user.TOTPEnabledAt, &user.Role, &user.SuspendedUntil, &user.BannedAt,
+		&user.FailedLoginAttempts, &user.LastFailedLoginAt, &user.LockedUntil, &user.UsernameChangedAt, &user.DeletionScheduledAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 // Create inserts a new user into the database
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	query := `
@@ -37,7 +65,7 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+		return fmt.Errorf("failed to create user: %w", wrapPgError(err))
 	}
 
 	return nil
@@ -46,18 +74,13 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 // GetByID retrieves a user by their ID
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, full_name, bio, avatar, created_at, updated_at
+		SELECT` + userColumns + `
 		FROM users WHERE id = $1`
 
-	user := &model.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.Password,
-		&user.FullName, &user.Bio, &user.Avatar, &user.CreatedAt, &user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
@@ -68,18 +91,13 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User
 // GetByEmail retrieves a user by their email
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, full_name, bio, avatar, created_at, updated_at
+		SELECT` + userColumns + `
 		FROM users WHERE email = $1`
 
-	user := &model.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Username, &user.Email, &user.Password,
-		&user.FullName, &user.Bio, &user.Avatar, &user.CreatedAt, &user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -87,21 +105,27 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.U
 	return user, nil
 }
 
-// GetByUsername retrieves a user by their username
+// GetByUsername retrieves a user by their username, or by a username they gave
+// up that is still held for them. The current owner of a name always wins, so
+// check user.Username to tell whether an old handle was used.
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, full_name, bio, avatar, created_at, updated_at
-		FROM users WHERE username = $1`
-
-	user := &model.User{}
-	err := r.db.QueryRowContext(ctx, query, username).Scan(
-		&user.ID, &user.Username, &user.Email, &user.Password,
-		&user.FullName, &user.Bio, &user.Avatar, &user.CreatedAt, &user.UpdatedAt,
-	)
-
+		SELECT` + userColumns + `
+		FROM users
+		WHERE username = $1
+		   OR id = (
+			SELECT h.user_id FROM username_history h
+			WHERE h.username = $1 AND h.held_until > NOW()
+			ORDER BY h.changed_at DESC
+			LIMIT 1
+		   )
+		ORDER BY username = $1 DESC
+		LIMIT 1`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, username))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user by username: %w", err)
 	}
@@ -109,36 +133,302 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	return user, nil
 }
 
-// Update updates a user's information
-func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+// List retrieves a page of all users, newest first
+func (r *userRepository) List(ctx context.Context, page model.PageRequest) ([]*model.User, *model.Cursor, error) {
+	query := `
+		SELECT` + userColumns + `
+		FROM users
+		WHERE ($1::timestamptz IS NULL OR (created_at, id) < ($1, $2::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`
+
+	after, afterID := cursorArgs(page)
+	rows, err := r.db.QueryContext(ctx, query, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*model.User, 0, page.Limit+1)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	users, next := nextCursor(users, page.Limit, func(u *model.User) (time.Time, uuid.UUID) {
+		return u.CreatedAt, u.ID
+	})
+	return users, next, nil
+}
+
+// Update updates a user's information. When ifUpdatedAt is set the write only
+// happens if updated_at still matches it, otherwise ErrUserModified is returned.
+// user.UpdatedAt is set to the stored value, which Postgres rounds to microseconds.
+func (r *userRepository) Update(ctx context.Context, user *model.User, ifUpdatedAt *time.Time) error {
 	query := `
 		UPDATE users
 		SET username = $2, email = $3, full_name = $4, bio = $5, avatar = $6, updated_at = $7
+		WHERE id = $1 AND ($8::timestamptz IS NULL OR updated_at = $8)
+		RETURNING updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		user.ID, user.Username, user.Email, user.FullName,
+		user.Bio, user.Avatar, time.Now(), ifUpdatedAt,
+	).Scan(&user.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		if ifUpdatedAt == nil {
+			return ErrUserNotFound
+		}
+		return r.modifiedOrMissing(ctx, user.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", wrapPgError(err))
+	}
+
+	return nil
+}
+
+// modifiedOrMissing explains why a conditional update matched no rows
+func (r *userRepository) modifiedOrMissing(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	return ErrUserModified
+}
+
+// ChangeUsername renames the user, recording the old username in the history
+// so it stays held for them until holdUntil
+func (r *userRepository) ChangeUsername(ctx context.Context, id uuid.UUID, username string, holdUntil time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	var previous string
+	err = tx.QueryRowContext(ctx, `SELECT username FROM users WHERE id = $1 FOR UPDATE`, id).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	update := `
+		UPDATE users
+		SET username = $2, username_changed_at = $3, updated_at = $3
 		WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, update, id, username, now); err != nil {
+		return fmt.Errorf("failed to change username: %w", wrapPgError(err))
+	}
 
-	user.UpdatedAt = time.Now()
+	history := `
+		INSERT INTO username_history (id, user_id, username, changed_at, held_until)
+		VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.ExecContext(ctx, history, uuid.New(), id, previous, now, holdUntil); err != nil {
+		return fmt.Errorf("failed to record username history: %w", err)
+	}
 
-	result, err := r.db.ExecContext(ctx, query,
-		user.ID, user.Username, user.Email, user.FullName,
-		user.Bio, user.Avatar, user.UpdatedAt,
-	)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit username change: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that the user confirmed their email address
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, $2) WHERE id = $1`
 
+	result, err := r.db.ExecContext(ctx, query, id, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return checkUserAffected(result)
+}
+
+// UpdatePassword replaces the user's password hash
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2, updated_at = $3 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, passwordHash, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return checkUserAffected(result)
+}
+
+// UpdateRole changes the user's role
+func (r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role model.Role) error {
+	query := `UPDATE users SET role = $2, updated_at = $3 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, role, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	return checkUserAffected(result)
+}
+
+// SetPendingTOTPSecret stores a new authenticator secret that isn't active until EnableTOTP
+func (r *userRepository) SetPendingTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	query := `UPDATE users SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, secret)
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	return checkUserAffected(result)
+}
+
+// EnableTOTP activates the pending secret, recording step as already used
+func (r *userRepository) EnableTOTP(ctx context.Context, id uuid.UUID, step int64) error {
+	query := `UPDATE users SET totp_enabled_at = $2, totp_last_step = $3 WHERE id = $1 AND totp_secret IS NOT NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, time.Now(), step)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	return checkUserAffected(result)
+}
+
+// DisableTOTP removes the user's authenticator secret
+func (r *userRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to disable totp: %w", err)
+	}
+
+	return checkUserAffected(result)
+}
+
+// RecordTOTPStep marks a time step as used. It returns false if that step or a
+// later one was already accepted, which means the code is being replayed.
+func (r *userRepository) RecordTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	query := `
+		UPDATE users SET totp_last_step = $2
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`
+
+	result, err := r.db.ExecContext(ctx, query, id, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record totp step: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+	return rowsAffected > 0, nil
+}
+
+// RecordLoginFailure counts a failed login at at. The failure that brings the
+// count to threshold locks the account until lockUntil and resets the count;
+// it reports whether this failure did so.
+func (r *userRepository) RecordLoginFailure(ctx context.Context, id uuid.UUID, at time.Time, threshold int, lockUntil time.Time) (bool, error) {
+	query := `
+		UPDATE users SET
+			failed_login_attempts = CASE WHEN failed_login_attempts + 1 >= $3 THEN 0 ELSE failed_login_attempts + 1 END,
+			locked_until = CASE WHEN failed_login_attempts + 1 >= $3 THEN $4 ELSE locked_until END,
+			last_failed_login_at = $2
+		WHERE id = $1
+		RETURNING failed_login_attempts = 0`
+
+	var locked bool
+	err := r.db.QueryRowContext(ctx, query, id, at, threshold, lockUntil).Scan(&locked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrUserNotFound
+		}
+		return false, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return locked, nil
+}
+
+// ResetLoginFailures clears the failed login count and any lockout
+func (r *userRepository) ResetLoginFailures(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE users SET failed_login_attempts = 0, last_failed_login_at = NULL, locked_until = NULL
+		WHERE id = $1 AND (failed_login_attempts > 0 OR locked_until IS NOT NULL)`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
 	}
 
 	return nil
 }
 
+// ScheduleDeletion marks the account for deletion at at
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `UPDATE users SET deletion_scheduled_at = $2 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to schedule user deletion: %w", err)
+	}
+
+	return checkUserAffected(result)
+}
+
+// CancelDeletion clears a deletion that is still pending at now, reporting
+// whether there was one. A deletion already due is left for the purge.
+func (r *userRepository) CancelDeletion(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	query := `
+		UPDATE users SET deletion_scheduled_at = NULL
+		WHERE id = $1 AND deletion_scheduled_at > $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel user deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteScheduled removes every user whose deletion was due by now and
+// returns how many were deleted. Their posts, comments, follows, sessions and
+// the rest go with them through ON DELETE CASCADE.
+func (r *userRepository) DeleteScheduled(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deletion_scheduled_at <= $1`
+
+	result, err := r.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete scheduled users: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
 // Delete removes a user from the database
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
@@ -154,7 +444,21 @@ func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// checkUserAffected maps a zero-row write to ErrUserNotFound
+func checkUserAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
 	}
 
 	return nil
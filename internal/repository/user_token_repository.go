@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+)
+
+// ErrTokenInvalid is returned when a user token is unknown, expired or already used
+var ErrTokenInvalid = apperr.Validation("invalid_token", "token is invalid or has expired")
+
+type userTokenRepository struct {
+	db *sql.DB
+}
+
+// NewUserTokenRepository creates a new user token repository
+func NewUserTokenRepository(db *sql.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+// Create inserts a new user token into the database
+func (r *userTokenRepository) Create(ctx context.Context, token *model.UserToken) error {
+	query := `
+		INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt, token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %w", wrapPgError(err))
+	}
+
+	return nil
+}
+
+// Consume marks a token as used and returns it. The check and the update happen
+// in a single statement, so a token can only ever be redeemed once.
+func (r *userTokenRepository) Consume(ctx context.Context, purpose model.TokenPurpose, tokenHash string) (*model.UserToken, error) {
+	query := `
+		UPDATE user_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+
+	token := &model.UserToken{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash, purpose).Scan(
+		&token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to consume user token: %w", err)
+	}
+
+	return token, nil
+}
+
+// DeleteForUser removes the user's outstanding tokens for a purpose
+func (r *userTokenRepository) DeleteForUser(ctx context.Context, userID uuid.UUID, purpose model.TokenPurpose) error {
+	query := `DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, purpose); err != nil {
+		return fmt.Errorf("failed to delete user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStale removes tokens that expired before cutoff or were already used,
+// returning how many were deleted
+func (r *userTokenRepository) DeleteStale(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM user_tokens WHERE expires_at < $1 OR used_at IS NOT NULL`
+
+	result, err := r.db.ExecContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale user tokens: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted user tokens: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+// ErrDeletionScheduled is returned when deleting an account that is already due to be deleted
+var ErrDeletionScheduled = apperr.Conflict("deletion_scheduled", "account is already scheduled for deletion")
+
+// DeletionConfig controls self-service account deletion. A deleted account is
+// kept for GracePeriod, and logging in during that time cancels the deletion.
+type DeletionConfig struct {
+	GracePeriod time.Duration
+}
+
+// DeleteAccount schedules the user's account for deletion once the grace
+// period is over, after checking their password. Every session is revoked, so
+// signing in again is how the user changes their mind.
+func (s *userService) DeleteAccount(ctx context.Context, userID uuid.UUID, password string) (time.Time, error) {
+	ctx, span := tracing.Start(ctx, "userService.DeleteAccount")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := comparePassword(ctx, user.Password, password); err != nil {
+		return time.Time{}, ErrInvalidPassword
+	}
+	if user.DeletionScheduledAt != nil {
+		return time.Time{}, ErrDeletionScheduled
+	}
+
+	deleteAt := time.Now().Add(s.deletionCfg.GracePeriod)
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, deleteAt); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.authService.LogoutAll(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	slog.InfoContext(ctx, "account deletion scheduled", "user_id", userID, "at", deleteAt)
+	if err := s.accountService.SendDeletionNotice(ctx, user, deleteAt); err != nil {
+		slog.WarnContext(ctx, "failed to send deletion notice", "user_id", userID, "error", err)
+	}
+
+	return deleteAt, nil
+}
+
+// PurgeDeletedAccounts hard deletes the accounts whose grace period has run
+// out. It runs periodically as a background job.
+func (s *userService) PurgeDeletedAccounts(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "userService.PurgeDeletedAccounts")
+	defer span.End()
+
+	deleted, err := s.userRepo.DeleteScheduled(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "purged deleted accounts", "count", deleted)
+	}
+
+	return nil
+}
+
+// cancelDeletion clears a pending deletion when the user signs in again. Once
+// the grace period is over it's too late, and the login fails as if the
+// account were already gone.
+func cancelDeletion(ctx context.Context, userRepo repository.UserRepository, user *model.User, now time.Time) error {
+	if user.DeletionScheduledAt == nil {
+		return nil
+	}
+	if user.IsDeleted(now) {
+		return ErrInvalidCredentials
+	}
+
+	cancelled, err := userRepo.CancelDeletion(ctx, user.ID, now)
+	if err != nil {
+		return err
+	}
+	if cancelled {
+		slog.InfoContext(ctx, "account deletion cancelled by login", "user_id", user.ID)
+	}
+	user.DeletionScheduledAt = nil
+
+	return nil
+}
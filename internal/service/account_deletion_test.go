@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+)
+
+// deletionUserRepo records the deletions cancelled through it
+type deletionUserRepo struct {
+	fakeUserRepo
+	cancelled []uuid.UUID
+}
+
+func (r *deletionUserRepo) CancelDeletion(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	r.cancelled = append(r.cancelled, id)
+	return true, nil
+}
+
+type fakeLoginAttemptRepo struct {
+	repository.LoginAttemptRepository
+	attempts []*model.LoginAttempt
+}
+
+func (r *fakeLoginAttemptRepo) CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int, time.Time, error) {
+	return 0, time.Time{}, nil
+}
+
+func (r *fakeLoginAttemptRepo) Create(ctx context.Context, attempt *model.LoginAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func TestCancelDeletion(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		when := now.Add(d)
+		return &when
+	}
+
+	tests := []struct {
+		name          string
+		scheduledAt   *time.Time
+		want          error
+		wantCancelled bool
+	}{
+		{"nothing scheduled", nil, nil, false},
+		{"within grace period", at(time.Hour), nil, true},
+		{"due now", at(0), ErrInvalidCredentials, false},
+		{"past due", at(-time.Hour), ErrInvalidCredentials, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &deletionUserRepo{}
+			user := &model.User{ID: uuid.New(), DeletionScheduledAt: tt.scheduledAt}
+
+			err := cancelDeletion(context.Background(), repo, user, now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("cancelDeletion = %v, want %v", err, tt.want)
+			}
+			if cancelled := len(repo.cancelled) > 0; cancelled != tt.wantCancelled {
+				t.Errorf("cancelled = %v, want %v", cancelled, tt.wantCancelled)
+			}
+			if tt.wantCancelled && user.DeletionScheduledAt != nil {
+				t.Error("DeletionScheduledAt still set after cancelling")
+			}
+		})
+	}
+}
+
+func TestLoginAfterDeletionGracePeriod(t *testing.T) {
+	deleteAt := time.Now().Add(-time.Minute)
+	hash, err := hashPassword(context.Background(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &deletionUserRepo{fakeUserRepo: fakeUserRepo{users: []*model.User{{
+		ID:                  uuid.New(),
+		Email:               "jane@example.com",
+		Password:            string(hash),
+		DeletionScheduledAt: &deleteAt,
+	}}}}
+	attempts := &fakeLoginAttemptRepo{}
+	s := &userService{
+		userRepo: repo,
+		guard:    newLoginGuard(repo, attempts, nil, LoginConfig{IPMaxAttempts: 10, IPWindow: time.Hour}),
+	}
+
+	// The right password doesn't bring the account back once it's due
+	_, err = s.Login(context.Background(), "jane@example.com", "secret", model.ClientInfo{IPAddress: "192.0.2.1"})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if len(repo.cancelled) != 0 {
+		t.Error("deletion was cancelled after the grace period")
+	}
+	// Recorded like a login for an unknown email
+	if len(attempts.attempts) != 1 || attempts.attempts[0].UserID != nil || attempts.attempts[0].Success {
+		t.Errorf("attempts = %+v, want one failure without a user", attempts.attempts)
+	}
+}
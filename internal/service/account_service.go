@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/mailer"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+var (
+	// ErrInvalidToken is returned when a verification or reset token can't be redeemed
+	ErrInvalidToken = apperr.Validation("invalid_token", "token is invalid or has expired")
+	// ErrEmailAlreadyVerified is returned when re-sending verification for a verified address
+	ErrEmailAlreadyVerified = apperr.Conflict("email_already_verified", "email is already verified")
+)
+
+// AccountConfig controls account emails and their token lifetimes
+type AccountConfig struct {
+	// BaseURL is the client app's URL; links in emails point at BaseURL + path
+	BaseURL               string
+	VerificationTokenTTL  time.Duration
+	PasswordResetTokenTTL time.Duration
+}
+
+type accountService struct {
+	userRepo    repository.UserRepository
+	tokenRepo   repository.UserTokenRepository
+	authService AuthService
+	mailer      mailer.Mailer
+	cfg         AccountConfig
+}
+
+// NewAccountService creates a new account service
+func NewAccountService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.UserTokenRepository,
+	authService AuthService,
+	m mailer.Mailer,
+	cfg AccountConfig,
+) AccountService {
+	return &accountService{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		authService: authService,
+		mailer:      m,
+		cfg:         cfg,
+	}
+}
+
+// SendVerificationEmail emails the user a fresh verification link, invalidating older ones
+func (s *accountService) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "accountService.SendVerificationEmail")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(ctx, user.ID, model.TokenPurposeEmailVerification, s.cfg.VerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address:\n\n%s\n\nThis link expires in %s.\n",
+			user.FullName, s.link("/verify-email", token), s.cfg.VerificationTokenTTL),
+	})
+}
+
+// VerifyEmail redeems a verification token
+func (s *accountService) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "accountService.VerifyEmail")
+	defer span.End()
+
+	userToken, err := s.tokenRepo.Consume(ctx, model.TokenPurposeEmailVerification, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenInvalid) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	return s.userRepo.MarkEmailVerified(ctx, userToken.UserID)
+}
+
+// ForgotPassword emails a reset link if the address belongs to an account.
+// It reports success either way so callers can't probe which emails are registered.
+func (s *accountService) ForgotPassword(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "accountService.ForgotPassword")
+	defer span.End()
+
+	user, err := s.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.issueToken(ctx, user.ID, model.TokenPurposePasswordReset, s.cfg.PasswordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this link to choose a new password:\n\n%s\n\nThis link expires in %s. "+
+			"If you didn't ask for this, you can ignore this email.\n",
+			user.FullName, s.link("/reset-password", token), s.cfg.PasswordResetTokenTTL),
+	})
+}
+
+// ResetPassword redeems a reset token, sets the new password and signs out every session
+func (s *accountService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "accountService.ResetPassword")
+	defer span.End()
+
+	userToken, err := s.tokenRepo.Consume(ctx, model.TokenPurposePasswordReset, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenInvalid) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	hashedPassword, err := hashPassword(ctx, newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userToken.UserID, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	// A new password ends any lockout from someone guessing the old one
+	if err := s.userRepo.ResetLoginFailures(ctx, userToken.UserID); err != nil {
+		return err
+	}
+
+	// Any other outstanding reset links are now stale
+	if err := s.tokenRepo.DeleteForUser(ctx, userToken.UserID, model.TokenPurposePasswordReset); err != nil {
+		slog.WarnContext(ctx, "failed to clear reset tokens", "user_id", userToken.UserID, "error", err)
+	}
+
+	return s.authService.LogoutAll(ctx, userToken.UserID)
+}
+
+// SendLockoutNotice tells the user their account was locked after repeated
+// failed logins, in case someone else is guessing their password
+func (s *accountService) SendLockoutNotice(ctx context.Context, user *model.User, until time.Time) error {
+	ctx, span := tracing.Start(ctx, "accountService.SendLockoutNotice")
+	defer span.End()
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account was temporarily locked",
+		Body: fmt.Sprintf("Hi %s,\n\nAfter several failed sign-in attempts we locked your account until %s.\n\n"+
+			"If this wasn't you, someone may be trying to guess your password. Resetting it also lifts the lock:\n\n%s\n",
+			user.FullName, until.UTC().Format(time.RFC1123), strings.TrimRight(s.cfg.BaseURL, "/")+"/forgot-password"),
+	})
+}
+
+// SendDeletionNotice confirms that the user's account will be deleted at
+// deleteAt and tells them how to keep it
+func (s *accountService) SendDeletionNotice(ctx context.Context, user *model.User, deleteAt time.Time) error {
+	ctx, span := tracing.Start(ctx, "accountService.SendDeletionNotice")
+	defer span.End()
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf("Hi %s,\n\nAs requested, your account and everything you've posted will be permanently deleted on %s. "+
+			"You've been signed out on all devices.\n\n"+
+			"Changed your mind? Just sign in again before then and the deletion is cancelled:\n\n%s\n",
+			user.FullName, deleteAt.UTC().Format(time.RFC1123), strings.TrimRight(s.cfg.BaseURL, "/")+"/login"),
+	})
+}
+
+// issueToken replaces the user's outstanding tokens for purpose with a new one
+func (s *accountService) issueToken(ctx context.Context, userID uuid.UUID, purpose model.TokenPurpose, ttl time.Duration) (string, error) {
+	if err := s.tokenRepo.DeleteForUser(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	err = s.tokenRepo.Create(ctx, &model.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// link builds the client URL an emailed token should be opened at
+func (s *accountService) link(path, token string) string {
+	return strings.TrimRight(s.cfg.BaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// PurgeStaleTokens deletes verification and reset tokens that can no longer be
+// redeemed. It runs periodically as a background job.
+func (s *accountService) PurgeStaleTokens(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "accountService.PurgeStaleTokens")
+	defer span.End()
+
+	deleted, err := s.tokenRepo.DeleteStale(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "purged stale account tokens", "count", deleted)
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+var (
+	// ErrCannotManageSelf is returned when a staff member targets their own account
+	ErrCannotManageSelf = apperr.Forbidden("cannot_manage_self", "you cannot perform this action on your own account")
+	// ErrInsufficientRole is returned when the target's role is at or above the actor's
+	ErrInsufficientRole = apperr.Forbidden("insufficient_role", "you cannot manage a user with an equal or higher role")
+	// ErrInvalidRole is returned for a role name that doesn't exist
+	ErrInvalidRole = apperr.Validation("invalid_role", "invalid role")
+	// ErrInvalidSuspension is returned for a suspension that doesn't end in the future
+	ErrInvalidSuspension = apperr.Validation("invalid_suspension", "suspension must end in the future")
+)
+
+type adminService struct {
+	userRepo       repository.UserRepository
+	moderationRepo repository.ModerationRepository
+	authService    AuthService
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(
+	userRepo repository.UserRepository,
+	moderationRepo repository.ModerationRepository,
+	authService AuthService,
+) AdminService {
+	return &adminService{
+		userRepo:       userRepo,
+		moderationRepo: moderationRepo,
+		authService:    authService,
+	}
+}
+
+// ListUsers retrieves a page of all users, newest first
+func (s *adminService) ListUsers(ctx context.Context, page model.PageRequest) ([]*model.UserResponse, *model.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "adminService.ListUsers")
+	defer span.End()
+
+	users, next, err := s.userRepo.List(ctx, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	responses := make([]*model.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = user.ToOwnerResponse()
+	}
+
+	return responses, next, nil
+}
+
+// SuspendUser blocks the user from signing in or using their sessions until
+// the given time. Their sessions are kept and work again once it passes.
+func (s *adminService) SuspendUser(ctx context.Context, actor *model.Principal, userID uuid.UUID, until time.Time, reason string) error {
+	ctx, span := tracing.Start(ctx, "adminService.SuspendUser")
+	defer span.End()
+
+	if !until.After(time.Now()) {
+		return ErrInvalidSuspension
+	}
+
+	return s.moderate(ctx, actor, userID, model.ModerationSuspend, reason, &until)
+}
+
+// UnsuspendUser lifts a suspension early
+func (s *adminService) UnsuspendUser(ctx context.Context, actor *model.Principal, userID uuid.UUID, reason string) error {
+	ctx, span := tracing.Start(ctx, "adminService.UnsuspendUser")
+	defer span.End()
+
+	return s.moderate(ctx, actor, userID, model.ModerationUnsuspend, reason, nil)
+}
+
+// BanUser permanently blocks the user and hides their content. Their sessions
+// are revoked, so lifting the ban requires them to sign in again.
+func (s *adminService) BanUser(ctx context.Context, actor *model.Principal, userID uuid.UUID, reason string) error {
+	ctx, span := tracing.Start(ctx, "adminService.BanUser")
+	defer span.End()
+
+	if err := s.moderate(ctx, actor, userID, model.ModerationBan, reason, nil); err != nil {
+		return err
+	}
+
+	return s.authService.LogoutAll(ctx, userID)
+}
+
+// UnbanUser lifts a ban
+func (s *adminService) UnbanUser(ctx context.Context, actor *model.Principal, userID uuid.UUID, reason string) error {
+	ctx, span := tracing.Start(ctx, "adminService.UnbanUser")
+	defer span.End()
+
+	return s.moderate(ctx, actor, userID, model.ModerationUnban, reason, nil)
+}
+
+// ListModerationActions retrieves a page of the suspensions and bans applied to a user
+func (s *adminService) ListModerationActions(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.ModerationAction, *model.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "adminService.ListModerationActions")
+	defer span.End()
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	return s.moderationRepo.ListForUser(ctx, userID, page)
+}
+
+// DeleteUser removes the user and, through cascading foreign keys, everything they own
+func (s *adminService) DeleteUser(ctx context.Context, actor *model.Principal, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "adminService.DeleteUser")
+	defer span.End()
+
+	if _, err := s.manageableUser(ctx, actor, userID); err != nil {
+		return err
+	}
+
+	return s.userRepo.Delete(ctx, userID)
+}
+
+// ChangeRole sets the user's role. Their sessions are revoked so the new role
+// can't be outlived by access tokens carrying the old one.
+func (s *adminService) ChangeRole(ctx context.Context, actor *model.Principal, userID uuid.UUID, role model.Role) (*model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "adminService.ChangeRole")
+	defer span.End()
+
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+
+	user, err := s.manageableUser(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role == role {
+		return user.ToOwnerResponse(), nil
+	}
+
+	if err := s.userRepo.UpdateRole(ctx, userID, role); err != nil {
+		return nil, err
+	}
+
+	if err := s.authService.LogoutAll(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	return user.ToOwnerResponse(), nil
+}
+
+// moderate checks the actor may act on the user, then applies and records the action
+func (s *adminService) moderate(ctx context.Context, actor *model.Principal, userID uuid.UUID, action model.ModerationActionType, reason string, expiresAt *time.Time) error {
+	if _, err := s.manageableUser(ctx, actor, userID); err != nil {
+		return err
+	}
+
+	return s.moderationRepo.Apply(ctx, &model.ModerationAction{
+		UserID:    userID,
+		ActorID:   &actor.UserID,
+		Action:    action,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// manageableUser loads the target user and checks the actor may act on them.
+// Admins may manage anyone but themselves; other staff only users below them.
+func (s *adminService) manageableUser(ctx context.Context, actor *model.Principal, userID uuid.UUID) (*model.User, error) {
+	if actor.UserID == userID {
+		return nil, ErrCannotManageSelf
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.Role != model.RoleAdmin && !actor.Role.Outranks(user.Role) {
+		return nil, ErrInsufficientRole
+	}
+
+	return user, nil
+}
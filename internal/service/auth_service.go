@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/jwtkeys"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = apperr.Unauthorized("invalid_refresh_token", "invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again
+	ErrRefreshTokenReused = apperr.Unauthorized("refresh_token_reused", "refresh token reuse detected; all sessions in this family were revoked")
+	// ErrSessionRevoked is returned when an access token belongs to a revoked session
+	ErrSessionRevoked = apperr.Unauthorized("session_revoked", "session has been revoked")
+	// ErrInvalidChallenge is returned for an expired or malformed two-factor challenge token
+	ErrInvalidChallenge = apperr.Unauthorized("invalid_challenge", "login challenge is invalid or has expired")
+	// ErrAccountSuspended is returned, wrapped with the end date, while a suspension is in effect
+	ErrAccountSuspended = apperr.Forbidden("account_suspended", "account is suspended")
+	// ErrAccountBanned is returned for banned accounts
+	ErrAccountBanned = apperr.Forbidden("account_banned", "account is banned")
+)
+
+// Token types, carried in the "typ" claim so one kind of token can't stand in for another
+const (
+	tokenTypeAccess    = "access"
+	tokenTypeChallenge = "mfa_challenge"
+)
+
+// challengeTTL bounds how long a user has to enter their code after the password step
+const challengeTTL = 5 * time.Minute
+
+// TokenConfig controls how access and refresh tokens are issued. Issuer and
+// Audience are set on every JWT and required when one is verified.
+type TokenConfig struct {
+	Keys            *jwtkeys.KeyRing
+	Issuer          string
+	Audience        string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type authService struct {
+	sessionRepo repository.SessionRepository
+	userRepo    repository.UserRepository
+	cfg         TokenConfig
+}
+
+// NewAuthService creates a new auth service
+func NewAuthService(sessionRepo repository.SessionRepository, userRepo repository.UserRepository, cfg TokenConfig) AuthService {
+	return &authService{
+		sessionRepo: sessionRepo,
+		userRepo:    userRepo,
+		cfg:         cfg,
+	}
+}
+
+// IssueTokens starts a new session family for the user and returns its first token pair
+func (s *authService) IssueTokens(ctx context.Context, userID uuid.UUID, client model.ClientInfo) (*model.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "authService.IssueTokens")
+	defer span.End()
+
+	refreshToken, session, err := s.newSession(userID, uuid.New(), client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.tokenPair(ctx, userID, session.FamilyID, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new pair, rotating the refresh token.
+// Presenting a token that was already rotated revokes its whole family.
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client model.ClientInfo) (*model.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "authService.Refresh")
+	defer span.End()
+
+	current, err := s.sessionRepo.GetByTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if current.RotatedAt != nil {
+		return nil, s.revokeForReuse(ctx, current.FamilyID)
+	}
+	if current.RevokedAt != nil || time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	nextToken, next, err := s.newSession(current.UserID, current.FamilyID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Rotate(ctx, current.ID, next); err != nil {
+		if errors.Is(err, repository.ErrSessionInactive) {
+			// Another request rotated this token first
+			return nil, s.revokeForReuse(ctx, current.FamilyID)
+		}
+		return nil, err
+	}
+
+	return s.tokenPair(ctx, current.UserID, current.FamilyID, nextToken)
+}
+
+// Logout revokes the session family the refresh token belongs to
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "authService.Logout")
+	defer span.End()
+
+	session, err := s.sessionRepo.GetByTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		return err
+	}
+
+	return s.sessionRepo.RevokeFamily(ctx, session.FamilyID)
+}
+
+// LogoutAll revokes every session belonging to the user
+func (s *authService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "authService.LogoutAll")
+	defer span.End()
+
+	return s.sessionRepo.RevokeAllForUser(ctx, userID)
+}
+
+// ValidateAccessToken validates an access token, checks that its session is
+// still active and returns the caller it identifies
+func (s *authService) ValidateAccessToken(ctx context.Context, tokenString string) (*model.Principal, error) {
+	ctx, span := tracing.Start(ctx, "authService.ValidateAccessToken")
+	defer span.End()
+
+	claims, err := s.parse(tokenString, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuidClaim(claims, "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := uuidClaim(claims, "sid")
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.sessionRepo.IsFamilyActive(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrSessionRevoked
+	}
+
+	// Restrictions take effect immediately rather than when the token expires
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRestrictions(user); err != nil {
+		return nil, err
+	}
+
+	// The role claim is only informational for clients; permissions follow
+	// the stored role so a change can't be outlived by an older token.
+	return &model.Principal{UserID: userID, Role: user.Role}, nil
+}
+
+// IssueChallenge signs a short-lived token proving the user passed the password step
+func (s *authService) IssueChallenge(userID uuid.UUID) (string, error) {
+	now := time.Now()
+	return s.sign(jwt.MapClaims{
+		"typ":     tokenTypeChallenge,
+		"user_id": userID.String(),
+		"exp":     now.Add(challengeTTL).Unix(),
+		"iat":     now.Unix(),
+	})
+}
+
+// ParseChallenge validates a challenge token and returns the user it was issued to
+func (s *authService) ParseChallenge(tokenString string) (uuid.UUID, error) {
+	claims, err := s.parse(tokenString, tokenTypeChallenge)
+	if err != nil {
+		return uuid.UUID{}, ErrInvalidChallenge
+	}
+
+	userID, err := uuidClaim(claims, "user_id")
+	if err != nil {
+		return uuid.UUID{}, ErrInvalidChallenge
+	}
+
+	return userID, nil
+}
+
+// JWKS returns the public keys that verify tokens issued by this service
+func (s *authService) JWKS() *jwtkeys.JWKSet {
+	return s.cfg.Keys.JWKS()
+}
+
+// sign stamps the issuer and audience onto claims and signs them with the active key
+func (s *authService) sign(claims jwt.MapClaims) (string, error) {
+	claims["iss"] = s.cfg.Issuer
+	claims["aud"] = s.cfg.Audience
+	return s.cfg.Keys.Sign(claims)
+}
+
+// parse verifies a JWT's signature, expiry, issuer and audience and checks it
+// is of the expected type
+func (s *authService) parse(tokenString, tokenType string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, s.cfg.Keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if !claims.VerifyIssuer(s.cfg.Issuer, true) {
+		return nil, fmt.Errorf("unexpected token issuer")
+	}
+	if !claims.VerifyAudience(s.cfg.Audience, true) {
+		return nil, fmt.Errorf("unexpected token audience")
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenType {
+		return nil, fmt.Errorf("unexpected token type %q", typ)
+	}
+
+	return claims, nil
+}
+
+// newSession builds a session row and returns it together with the raw refresh token
+func (s *authService) newSession(userID, familyID uuid.UUID, client model.ClientInfo) (string, *model.Session, error) {
+	refreshToken, err := generateToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return refreshToken, &model.Session{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTokenTTL),
+	}, nil
+}
+
+// tokenPair signs an access token for the session family and pairs it with the
+// refresh token. The role is read fresh so a role change applies from the next refresh.
+func (s *authService) tokenPair(ctx context.Context, userID, familyID uuid.UUID, refreshToken string) (*model.TokenPair, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRestrictions(user); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	accessToken, err := s.sign(jwt.MapClaims{
+		"typ":     tokenTypeAccess,
+		"user_id": userID.String(),
+		"sid":     familyID.String(),
+		"role":    string(user.Role),
+		"exp":     now.Add(s.cfg.AccessTokenTTL).Unix(),
+		"iat":     now.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// revokeForReuse revokes a family after refresh token reuse and reports it
+func (s *authService) revokeForReuse(ctx context.Context, familyID uuid.UUID) error {
+	if err := s.sessionRepo.RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// checkRestrictions rejects banned users and users serving a suspension,
+// telling the latter when it ends
+func checkRestrictions(user *model.User) error {
+	if user.IsBanned() {
+		return ErrAccountBanned
+	}
+	if user.IsSuspended(time.Now()) {
+		return apperr.WithDetail(ErrAccountSuspended, "suspended_until", user.SuspendedUntil.UTC())
+	}
+	return nil
+}
+
+// uuidClaim reads a UUID-valued string claim
+func uuidClaim(claims jwt.MapClaims, name string) (uuid.UUID, error) {
+	raw, ok := claims[name].(string)
+	if !ok {
+		return uuid.UUID{}, fmt.Errorf("invalid %s in token", name)
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("invalid %s format: %w", name, err)
+	}
+
+	return id, nil
+}
+
+// generateToken returns a random URL-safe opaque token
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of an opaque token for storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
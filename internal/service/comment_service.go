@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+var (
+	// ErrCannotDeleteComment is returned when someone other than the comment author or post owner deletes a comment
+	ErrCannotDeleteComment = apperr.Forbidden("cannot_delete_comment", "only the comment author or the post owner can delete this comment")
+	// ErrCommentContentRequired is returned when a comment's content is blank
+	ErrCommentContentRequired = apperr.Validation("content_required", "comment content is required")
+)
+
+type commentService struct {
+	commentRepo repository.CommentRepository
+	postRepo    repository.PostRepository
+}
+
+// NewCommentService creates a new comment service
+func NewCommentService(commentRepo repository.CommentRepository, postRepo repository.PostRepository) CommentService {
+	return &commentService{
+		commentRepo: commentRepo,
+		postRepo:    postRepo,
+	}
+}
+
+// CreateComment adds a comment from the user to a post. Posts by banned
+// users are hidden, so they can't be commented on either.
+func (s *commentService) CreateComment(ctx context.Context, userID, postID uuid.UUID, req *model.CommentRequest) (*model.Comment, error) {
+	ctx, span := tracing.Start(ctx, "commentService.CreateComment")
+	defer span.End()
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, ErrCommentContentRequired
+	}
+
+	if _, err := s.postRepo.GetById(ctx, postID, userID); err != nil {
+		return nil, err
+	}
+
+	comment := &model.Comment{
+		PostID:  postID,
+		UserID:  userID,
+		Content: content,
+	}
+
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	// Re-read so the response carries the joined author
+	return s.commentRepo.GetByID(ctx, comment.ID)
+}
+
+// GetComments retrieves a page of a post's comments
+func (s *commentService) GetComments(ctx context.Context, postID uuid.UUID, page model.PageRequest) ([]*model.Comment, *model.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "commentService.GetComments")
+	defer span.End()
+
+	if _, err := s.postRepo.GetById(ctx, postID, uuid.Nil); err != nil {
+		return nil, nil, err
+	}
+
+	return s.commentRepo.GetByPostID(ctx, postID, page)
+}
+
+// DeleteComment removes a comment if the user wrote it or owns the post
+func (s *commentService) DeleteComment(ctx context.Context, userID, commentID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "commentService.DeleteComment")
+	defer span.End()
+
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		post, err := s.postRepo.GetById(ctx, comment.PostID, userID)
+		if err != nil {
+			return err
+		}
+		if post.UserID != userID {
+			return ErrCannotDeleteComment
+		}
+	}
+
+	return s.commentRepo.Delete(ctx, commentID)
+}
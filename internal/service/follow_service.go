@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/metrics"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+// ErrCannotFollowSelf is returned when a user tries to follow or unfollow themselves
+var ErrCannotFollowSelf = apperr.Validation("cannot_follow_self", "you cannot follow yourself")
+
+type followService struct {
+	followRepo repository.FollowRepository
+	userRepo   repository.UserRepository
+}
+
+// NewFollowService creates a new follow service
+func NewFollowService(followRepo repository.FollowRepository, userRepo repository.UserRepository) FollowService {
+	return &followService{
+		followRepo: followRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// Follow makes followerID follow followedID
+func (s *followService) Follow(ctx context.Context, followerID, followedID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "followService.Follow")
+	defer span.End()
+
+	if followerID == followedID {
+		return ErrCannotFollowSelf
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, followedID); err != nil {
+		return err
+	}
+
+	created, err := s.followRepo.Follow(ctx, followerID, followedID)
+	if errors.Is(err, repository.ErrSelfFollow) {
+		return ErrCannotFollowSelf
+	}
+	if err != nil {
+		return err
+	}
+
+	if created {
+		metrics.Follow()
+	}
+	return nil
+}
+
+// Unfollow makes followerID stop following followedID
+func (s *followService) Unfollow(ctx context.Context, followerID, followedID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "followService.Unfollow")
+	defer span.End()
+
+	if followerID == followedID {
+		return ErrCannotFollowSelf
+	}
+
+	return s.followRepo.Unfollow(ctx, followerID, followedID)
+}
+
+// GetFollowers retrieves a page of the users following userID
+func (s *followService) GetFollowers(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.UserResponse, *model.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "followService.GetFollowers")
+	defer span.End()
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	users, next, err := s.followRepo.GetFollowers(ctx, userID, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return toUserResponses(users), next, nil
+}
+
+// GetFollowing retrieves a page of the users userID follows
+func (s *followService) GetFollowing(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.UserResponse, *model.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "followService.GetFollowing")
+	defer span.End()
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	users, next, err := s.followRepo.GetFollowing(ctx, userID, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return toUserResponses(users), next, nil
+}
+
+// toUserResponses converts users into their public JSON representation
+func toUserResponses(users []*model.User) []*model.UserResponse {
+	responses := make([]*model.UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, user.ToResponse())
+	}
+	return responses
+}
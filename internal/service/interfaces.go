@@ -2,15 +2,91 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/naval1525/Social_Media_Backend/internal/jwtkeys"
 	"github.com/naval1525/Social_Media_Backend/internal/model"
 )
 
 type UserService interface {
 	Register(ctx context.Context, req *model.UserRequest) (*model.UserResponse, error)
-	Login(ctx context.Context, email, password string) (*model.UserResponse, string, error)
-	GetProfile(ctx context.Context, userID uuid.UUID) (*model.UserResponse, error)
-	UpdateProfile(ctx context.Context, userID uuid.UUID, updates map[string]interface{}) (*model.UserResponse, error)
-    ValidateJWT(tokenString string) (uuid.UUID, error)
+	Login(ctx context.Context, email, password string, client model.ClientInfo) (*model.LoginResult, error)
+	ListLoginHistory(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.LoginAttempt, *model.Cursor, error)
+	GetProfile(ctx context.Context, viewerID, userID uuid.UUID) (*model.UserResponse, error)
+	GetProfileByUsername(ctx context.Context, viewerID uuid.UUID, username string) (*model.UserResponse, error)
+	UpdateProfile(ctx context.Context, userID uuid.UUID, req *model.UpdateProfileRequest, ifUpdatedAt *time.Time) (*model.UserResponse, error)
+	ChangeUsername(ctx context.Context, userID uuid.UUID, username string) (*model.UserResponse, error)
+	DeleteAccount(ctx context.Context, userID uuid.UUID, password string) (time.Time, error)
+	PurgeDeletedAccounts(ctx context.Context) error
+}
+
+// AuthService issues short-lived access tokens paired with rotating refresh tokens
+type AuthService interface {
+	IssueTokens(ctx context.Context, userID uuid.UUID, client model.ClientInfo) (*model.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client model.ClientInfo) (*model.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutAll(ctx context.Context, userID uuid.UUID) error
+	ValidateAccessToken(ctx context.Context, tokenString string) (*model.Principal, error)
+	IssueChallenge(userID uuid.UUID) (string, error)
+	ParseChallenge(tokenString string) (uuid.UUID, error)
+	JWKS() *jwtkeys.JWKSet
+}
+
+// TwoFactorService manages TOTP enrollment and the second step of login
+type TwoFactorService interface {
+	Enroll(ctx context.Context, userID uuid.UUID) (*model.TOTPEnrollment, error)
+	Confirm(ctx context.Context, userID uuid.UUID, code string, client model.ClientInfo) ([]string, error)
+	Disable(ctx context.Context, userID uuid.UUID, password, code string, client model.ClientInfo) error
+	CompleteLogin(ctx context.Context, challengeToken, code string, client model.ClientInfo) (*model.LoginResult, error)
+}
+
+type PostService interface {
+	CreatePost(ctx context.Context, userID uuid.UUID, req *model.PostRequest) (*model.Post, error)
+	GetPost(ctx context.Context, viewerID, postID uuid.UUID) (*model.Post, error)
+	GetUserPosts(ctx context.Context, viewerID, userID uuid.UUID, page model.PageRequest) ([]*model.Post, *model.Cursor, error)
+	GetFeed(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.Post, *model.Cursor, error)
+	UpdatePost(ctx context.Context, userID, postID uuid.UUID, req *model.PostRequest) (*model.Post, error)
+	DeletePost(ctx context.Context, userID, postID uuid.UUID) error
+	LikePost(ctx context.Context, userID, postID uuid.UUID) (int, error)
+	UnlikePost(ctx context.Context, userID, postID uuid.UUID) (int, error)
+}
+
+type FollowService interface {
+	Follow(ctx context.Context, followerID, followedID uuid.UUID) error
+	Unfollow(ctx context.Context, followerID, followedID uuid.UUID) error
+	GetFollowers(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.UserResponse, *model.Cursor, error)
+	GetFollowing(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.UserResponse, *model.Cursor, error)
+}
+
+type CommentService interface {
+	CreateComment(ctx context.Context, userID, postID uuid.UUID, req *model.CommentRequest) (*model.Comment, error)
+	GetComments(ctx context.Context, postID uuid.UUID, page model.PageRequest) ([]*model.Comment, *model.Cursor, error)
+	DeleteComment(ctx context.Context, userID, commentID uuid.UUID) error
+}
+
+// AccountService handles email verification and password recovery
+type AccountService interface {
+	SendVerificationEmail(ctx context.Context, userID uuid.UUID) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	SendLockoutNotice(ctx context.Context, user *model.User, until time.Time) error
+	SendDeletionNotice(ctx context.Context, user *model.User, deleteAt time.Time) error
+	PurgeStaleTokens(ctx context.Context) error
+}
+
+// AdminService implements staff operations on user accounts. Callers must
+// already hold the matching permission; the service only checks that the
+// actor outranks the account being managed. Users are returned in the owner
+// view, so staff see emails and moderation state.
+type AdminService interface {
+	ListUsers(ctx context.Context, page model.PageRequest) ([]*model.UserResponse, *model.Cursor, error)
+	SuspendUser(ctx context.Context, actor *model.Principal, userID uuid.UUID, until time.Time, reason string) error
+	UnsuspendUser(ctx context.Context, actor *model.Principal, userID uuid.UUID, reason string) error
+	BanUser(ctx context.Context, actor *model.Principal, userID uuid.UUID, reason string) error
+	UnbanUser(ctx context.Context, actor *model.Principal, userID uuid.UUID, reason string) error
+	ListModerationActions(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.ModerationAction, *model.Cursor, error)
+	DeleteUser(ctx context.Context, actor *model.Principal, userID uuid.UUID) error
+	ChangeRole(ctx context.Context, actor *model.Principal, userID uuid.UUID, role model.Role) (*model.UserResponse, error)
 }
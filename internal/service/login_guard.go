@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+)
+
+var (
+	// ErrAccountLocked is returned while an account is locked after repeated failed logins
+	ErrAccountLocked = errors.New("account is temporarily locked after too many failed login attempts")
+	// ErrTooManyLoginAttempts is returned when retrying a login before the backoff delay has passed
+	ErrTooManyLoginAttempts = errors.New("too many login attempts")
+)
+
+// LoginThrottledError is returned for a login refused by brute-force
+// protection. It unwraps to ErrAccountLocked or ErrTooManyLoginAttempts.
+type LoginThrottledError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s, try again in %s", e.Err, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// LoginConfig controls brute-force protection on login. Each consecutive
+// failure makes the account wait BackoffBase before the next attempt, doubling
+// every time, and MaxAttempts failures lock it for LockoutDuration. An IP with
+// IPMaxAttempts failures within IPWindow is refused outright.
+type LoginConfig struct {
+	MaxAttempts     int
+	LockoutDuration time.Duration
+	BackoffBase     time.Duration
+	IPMaxAttempts   int
+	IPWindow        time.Duration
+}
+
+// loginGuard applies brute-force protection and keeps the login history for
+// every check of a secret: the password and two-factor code at login, and the
+// code and password that confirm or disable two-factor. A wrong answer at any
+// of them counts against the account, and only a login that passed every step
+// resets the count and is recorded as a success.
+type loginGuard struct {
+	userRepo         repository.UserRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	accountService   AccountService
+	cfg              LoginConfig
+}
+
+func newLoginGuard(
+	userRepo repository.UserRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	accountService AccountService,
+	cfg LoginConfig,
+) *loginGuard {
+	return &loginGuard{
+		userRepo:         userRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		accountService:   accountService,
+		cfg:              cfg,
+	}
+}
+
+// checkIPThrottle refuses ip once it has IPMaxAttempts failures within IPWindow,
+// until the oldest of them ages out
+func (g *loginGuard) checkIPThrottle(ctx context.Context, ip string, now time.Time) error {
+	failures, oldest, err := g.loginAttemptRepo.CountFailuresByIP(ctx, ip, now.Add(-g.cfg.IPWindow))
+	if err != nil {
+		return err
+	}
+	if failures < g.cfg.IPMaxAttempts {
+		return nil
+	}
+
+	return &LoginThrottledError{Err: ErrTooManyLoginAttempts, RetryAfter: oldest.Add(g.cfg.IPWindow).Sub(now)}
+}
+
+// checkAccount refuses a locked account, recording the attempt, or one that
+// is retrying before its backoff delay has passed
+func (g *loginGuard) checkAccount(ctx context.Context, user *model.User, client model.ClientInfo, now time.Time) error {
+	if user.IsLocked(now) {
+		g.recordAttempt(ctx, &user.ID, client, false)
+		return &LoginThrottledError{Err: ErrAccountLocked, RetryAfter: user.LockedUntil.Sub(now)}
+	}
+	if wait := g.backoff(user, now); wait > 0 {
+		return &LoginThrottledError{Err: ErrTooManyLoginAttempts, RetryAfter: wait}
+	}
+	return nil
+}
+
+// backoff returns how much longer user must wait before another attempt:
+// BackoffBase after the first failure, doubling with each one after, and never
+// more than a lockout
+func (g *loginGuard) backoff(user *model.User, now time.Time) time.Duration {
+	if user.FailedLoginAttempts == 0 || user.LastFailedLoginAt == nil {
+		return 0
+	}
+
+	delay := g.cfg.LockoutDuration
+	if shift := user.FailedLoginAttempts - 1; shift < 32 {
+		if d := g.cfg.BackoffBase << shift; d < delay {
+			delay = d
+		}
+	}
+
+	return user.LastFailedLoginAt.Add(delay).Sub(now)
+}
+
+// recordFailure records a failed attempt and counts it against the account.
+// The failure that reaches MaxAttempts locks it and emails the owner, since
+// someone else may be guessing. It returns the error to give the caller:
+// failErr, or a *LoginThrottledError once the account is locked.
+func (g *loginGuard) recordFailure(ctx context.Context, user *model.User, client model.ClientInfo, now time.Time, failErr error) error {
+	g.recordAttempt(ctx, &user.ID, client, false)
+
+	lockUntil := now.Add(g.cfg.LockoutDuration)
+	locked, err := g.userRepo.RecordLoginFailure(ctx, user.ID, now, g.cfg.MaxAttempts, lockUntil)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return failErr
+	}
+
+	slog.WarnContext(ctx, "account locked after failed logins", "user_id", user.ID, "until", lockUntil)
+	if err := g.accountService.SendLockoutNotice(ctx, user, lockUntil); err != nil {
+		slog.WarnContext(ctx, "failed to send lockout notice", "user_id", user.ID, "error", err)
+	}
+
+	return &LoginThrottledError{Err: ErrAccountLocked, RetryAfter: g.cfg.LockoutDuration}
+}
+
+// recordSuccess clears the account's failures and records the login. Call it
+// only once the user has passed every step.
+func (g *loginGuard) recordSuccess(ctx context.Context, user *model.User, client model.ClientInfo) error {
+	if err := g.userRepo.ResetLoginFailures(ctx, user.ID); err != nil {
+		return err
+	}
+	g.recordAttempt(ctx, &user.ID, client, true)
+	return nil
+}
+
+// recordAttempt adds a login attempt to the history. A failure to record it
+// shouldn't decide the login, so it's only logged.
+func (g *loginGuard) recordAttempt(ctx context.Context, userID *uuid.UUID, client model.ClientInfo, success bool) {
+	err := g.loginAttemptRepo.Create(ctx, &model.LoginAttempt{
+		UserID:    userID,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Success:   success,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to record login attempt", "error", err)
+	}
+}
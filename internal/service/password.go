@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashPassword bcrypts password in its own span, since hashing is
+// deliberately slow and often dominates the requests that do it
+func hashPassword(ctx context.Context, password string) ([]byte, error) {
+	_, span := tracing.Start(ctx, "bcrypt.GenerateFromPassword")
+	defer span.End()
+
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// comparePassword reports whether password matches the bcrypt hash, in its own span
+func comparePassword(ctx context.Context, hash, password string) error {
+	_, span := tracing.Start(ctx, "bcrypt.CompareHashAndPassword")
+	defer span.End()
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+// dummyPasswordHash is compared against when a login names an unknown email,
+// so it costs the same bcrypt time as a wrong password for a real account
+var dummyPasswordHash = func() string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	return string(hash)
+}()
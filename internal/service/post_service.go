@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/metrics"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+var (
+	// ErrNotPostOwner is returned when a user tries to modify someone else's post
+	ErrNotPostOwner = apperr.Forbidden("not_post_owner", "you can only modify your own posts")
+	// ErrEmailNotVerified is returned when an unverified account tries to post while verification is required
+	ErrEmailNotVerified = apperr.Forbidden("email_not_verified", "please verify your email address before posting")
+	// ErrPostContentRequired is returned when a post's content is blank
+	ErrPostContentRequired = apperr.Validation("content_required", "post content is required")
+)
+
+type postService struct {
+	postRepo             repository.PostRepository
+	likeRepo             repository.LikeRepository
+	userRepo             repository.UserRepository
+	requireVerifiedEmail bool
+}
+
+// NewPostService creates a new post service. When requireVerifiedEmail is set,
+// accounts that haven't confirmed their email can't publish posts.
+func NewPostService(
+	postRepo repository.PostRepository,
+	likeRepo repository.LikeRepository,
+	userRepo repository.UserRepository,
+	requireVerifiedEmail bool,
+) PostService {
+	return &postService{
+		postRepo:             postRepo,
+		likeRepo:             likeRepo,
+		userRepo:             userRepo,
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
+}
+
+// CreatePost publishes a new post for the user
+func (s *postService) CreatePost(ctx context.Context, userID uuid.UUID, req *model.PostRequest) (*model.Post, error) {
+	ctx, span := tracing.Start(ctx, "postService.CreatePost")
+	defer span.End()
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, ErrPostContentRequired
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if s.requireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	post := &model.Post{
+		UserID:   userID,
+		Content:  content,
+		ImageURL: req.ImageURL,
+	}
+
+	if err := s.postRepo.Create(ctx, post); err != nil {
+		return nil, fmt.Errorf("failed to create post: %w", err)
+	}
+	metrics.PostCreated()
+
+	post.Author = &model.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		FullName:  user.FullName,
+		Bio:       user.Bio,
+		Avatar:    user.Avatar,
+		CreatedAt: user.CreatedAt,
+	}
+
+	return post, nil
+}
+
+// GetPost retrieves a single post as seen by viewerID (uuid.Nil for anonymous)
+func (s *postService) GetPost(ctx context.Context, viewerID, postID uuid.UUID) (*model.Post, error) {
+	ctx, span := tracing.Start(ctx, "postService.GetPost")
+	defer span.End()
+
+	return s.postRepo.GetById(ctx, postID, viewerID)
+}
+
+// GetUserPosts retrieves a page of a user's posts as seen by viewerID
+func (s *postService) GetUserPosts(ctx context.Context, viewerID, userID uuid.UUID, page model.PageRequest) ([]*model.Post, *model.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "postService.GetUserPosts")
+	defer span.End()
+
+	return s.postRepo.GetByUserId(ctx, userID, viewerID, page)
+}
+
+// GetFeed retrieves a page of the user's home timeline
+func (s *postService) GetFeed(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.Post, *model.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "postService.GetFeed")
+	defer span.End()
+
+	return s.postRepo.GetFeed(ctx, userID, page)
+}
+
+// UpdatePost edits a post owned by the user
+func (s *postService) UpdatePost(ctx context.Context, userID, postID uuid.UUID, req *model.PostRequest) (*model.Post, error) {
+	ctx, span := tracing.Start(ctx, "postService.UpdatePost")
+	defer span.End()
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, ErrPostContentRequired
+	}
+
+	post, err := s.getOwnedPost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	post.Content = content
+	post.ImageURL = req.ImageURL
+
+	if err := s.postRepo.Update(ctx, post); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// DeletePost removes a post owned by the user
+func (s *postService) DeletePost(ctx context.Context, userID, postID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "postService.DeletePost")
+	defer span.End()
+
+	if _, err := s.getOwnedPost(ctx, userID, postID); err != nil {
+		return err
+	}
+
+	return s.postRepo.Delete(ctx, postID)
+}
+
+// LikePost likes a post and returns its updated like count
+func (s *postService) LikePost(ctx context.Context, userID, postID uuid.UUID) (int, error) {
+	ctx, span := tracing.Start(ctx, "postService.LikePost")
+	defer span.End()
+
+	count, created, err := s.likeRepo.Like(ctx, userID, postID)
+	if err != nil {
+		return 0, err
+	}
+
+	if created {
+		metrics.Like()
+	}
+	return count, nil
+}
+
+// UnlikePost removes a like and returns the post's updated like count
+func (s *postService) UnlikePost(ctx context.Context, userID, postID uuid.UUID) (int, error) {
+	ctx, span := tracing.Start(ctx, "postService.UnlikePost")
+	defer span.End()
+
+	return s.likeRepo.Unlike(ctx, userID, postID)
+}
+
+// getOwnedPost loads a post and verifies the user owns it
+func (s *postService) getOwnedPost(ctx context.Context, userID, postID uuid.UUID) (*model.Post, error) {
+	post, err := s.postRepo.GetById(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.UserID != userID {
+		return nil, ErrNotPostOwner
+	}
+
+	return post, nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/metrics"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/totp"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+var (
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling an account that already has 2FA
+	ErrTwoFactorAlreadyEnabled = apperr.Conflict("two_factor_already_enabled", "two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnrolled is returned when confirming without a pending enrollment
+	ErrTwoFactorNotEnrolled = apperr.Validation("two_factor_not_enrolled", "start two-factor enrollment first")
+	// ErrTwoFactorNotEnabled is returned when disabling 2FA on an account without it
+	ErrTwoFactorNotEnabled = apperr.Validation("two_factor_not_enabled", "two-factor authentication is not enabled")
+	// ErrInvalidTwoFactorCode is returned for a wrong, expired or replayed code
+	ErrInvalidTwoFactorCode = apperr.Unauthorized("invalid_two_factor_code", "invalid two-factor code")
+	// ErrInvalidPassword is returned when re-authentication fails
+	ErrInvalidPassword = apperr.Unauthorized("invalid_password", "invalid password")
+)
+
+const (
+	// totpIssuer is the account issuer authenticator apps display
+	totpIssuer = "Social Media"
+	// totpSkew accepts codes from one step either side of now to allow for clock drift
+	totpSkew = 1
+	// recoveryCodeCount is how many single-use recovery codes are issued
+	recoveryCodeCount = 10
+)
+
+type twoFactorService struct {
+	userRepo     repository.UserRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	authService  AuthService
+	guard        *loginGuard
+}
+
+// NewTwoFactorService creates a new two-factor service
+func NewTwoFactorService(
+	userRepo repository.UserRepository,
+	recoveryRepo repository.RecoveryCodeRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	authService AuthService,
+	accountService AccountService,
+	loginCfg LoginConfig,
+) TwoFactorService {
+	return &twoFactorService{
+		userRepo:     userRepo,
+		recoveryRepo: recoveryRepo,
+		authService:  authService,
+		guard:        newLoginGuard(userRepo, loginAttemptRepo, accountService, loginCfg),
+	}
+}
+
+// Enroll generates a new secret for the user. It stays inactive until confirmed with a valid code.
+func (s *twoFactorService) Enroll(ctx context.Context, userID uuid.UUID) (*model.TOTPEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "twoFactorService.Enroll")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	if err := s.userRepo.SetPendingTOTPSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &model.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm activates the pending secret and returns a fresh set of recovery codes.
+// The plaintext codes are only ever shown here. Wrong codes are throttled like
+// failed logins.
+func (s *twoFactorService) Confirm(ctx context.Context, userID uuid.UUID, code string, client model.ClientInfo) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "twoFactorService.Confirm")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	var step int64
+	err = s.guarded(ctx, user, client, func() error {
+		var ok bool
+		if step, ok = totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew); !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recoveryRepo.ReplaceForUser(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.EnableTOTP(ctx, user.ID, step); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns off 2FA after checking both the password and a current or
+// recovery code. Wrong answers are throttled like failed logins.
+func (s *twoFactorService) Disable(ctx context.Context, userID uuid.UUID, password, code string, client model.ClientInfo) error {
+	ctx, span := tracing.Start(ctx, "twoFactorService.Disable")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.TOTPEnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	err = s.guarded(ctx, user, client, func() error {
+		if err := comparePassword(ctx, user.Password, password); err != nil {
+			return ErrInvalidPassword
+		}
+		return s.verifyCode(ctx, user, code)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.recoveryRepo.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return s.userRepo.DisableTOTP(ctx, user.ID)
+}
+
+// CompleteLogin finishes a two-step login by checking a code against the
+// challenge's user. A wrong code counts as a failed login, and only a correct
+// one resets the account's failures.
+func (s *twoFactorService) CompleteLogin(ctx context.Context, challengeToken, code string, client model.ClientInfo) (*model.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "twoFactorService.CompleteLogin")
+	defer span.End()
+
+	userID, err := s.authService.ParseChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.IsDeleted(now) {
+		return nil, ErrInvalidChallenge
+	}
+
+	if user.TOTPEnabledAt == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	if err := checkRestrictions(user); err != nil {
+		metrics.Login(metrics.LoginFailure)
+		s.guard.recordAttempt(ctx, &user.ID, client, false)
+		return nil, err
+	}
+
+	if err := s.guarded(ctx, user, client, func() error { return s.verifyCode(ctx, user, code) }); err != nil {
+		metrics.Login(metrics.LoginFailure)
+		return nil, err
+	}
+
+	if err := cancelDeletion(ctx, s.userRepo, user, now); err != nil {
+		return nil, err
+	}
+	if err := s.guard.recordSuccess(ctx, user, client); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.authService.IssueTokens(ctx, user.ID, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	metrics.Login(metrics.LoginSuccess)
+
+	return &model.LoginResult{User: user.ToOwnerResponse(), Tokens: tokens}, nil
+}
+
+// guarded runs check under the login guard: a throttled IP or account is
+// refused before checking anything, and a wrong code or password counts as a
+// failed attempt against the account
+func (s *twoFactorService) guarded(ctx context.Context, user *model.User, client model.ClientInfo, check func() error) error {
+	now := time.Now()
+	if err := s.guard.checkIPThrottle(ctx, client.IPAddress, now); err != nil {
+		return err
+	}
+	if err := s.guard.checkAccount(ctx, user, client, now); err != nil {
+		return err
+	}
+
+	err := check()
+	if errors.Is(err, ErrInvalidTwoFactorCode) || errors.Is(err, ErrInvalidPassword) {
+		return s.guard.recordFailure(ctx, user, client, now, err)
+	}
+	return err
+}
+
+// verifyCode accepts either a current TOTP code, once, or an unused recovery code
+func (s *twoFactorService) verifyCode(ctx context.Context, user *model.User, code string) error {
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew); ok {
+		fresh, err := s.userRepo.RecordTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.recoveryRepo.Consume(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// generateRecoveryCodes returns new codes formatted "xxxxx-xxxxx" and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode makes recovery codes case- and dash-insensitive
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -2,45 +2,91 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/metrics"
 	"github.com/naval1525/Social_Media_Backend/internal/model"
 	"github.com/naval1525/Social_Media_Backend/internal/repository"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the email or password is wrong
+	ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "invalid email or password")
+	// ErrFullNameRequired is returned when a profile update sets the full name to null or blank
+	ErrFullNameRequired = apperr.Validation("full_name_required", "full name cannot be removed")
 )
 
 type userService struct {
-	userRepo  repository.UserRepository
-	jwtSecret string
+	userRepo         repository.UserRepository
+	followRepo       repository.FollowRepository
+	postRepo         repository.PostRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	authService      AuthService
+	accountService   AccountService
+	guard            *loginGuard
+	usernameCfg      UsernameConfig
+	deletionCfg      DeletionConfig
 }
 
 // NewUserService creates a new user service
-func NewUserService(userRepo repository.UserRepository, jwtSecret string) UserService {
+func NewUserService(
+	userRepo repository.UserRepository,
+	followRepo repository.FollowRepository,
+	postRepo repository.PostRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	authService AuthService,
+	accountService AccountService,
+	loginCfg LoginConfig,
+	usernameCfg UsernameConfig,
+	deletionCfg DeletionConfig,
+) UserService {
 	return &userService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:         userRepo,
+		followRepo:       followRepo,
+		postRepo:         postRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		authService:      authService,
+		accountService:   accountService,
+		guard:            newLoginGuard(userRepo, loginAttemptRepo, accountService, loginCfg),
+		usernameCfg:      usernameCfg,
+		deletionCfg:      deletionCfg,
 	}
 }
 
 // Register creates a new user account
 func (s *userService) Register(ctx context.Context, req *model.UserRequest) (*model.UserResponse, error) {
-	// Check if user already exists
-	existingUser, _ := s.userRepo.GetByEmail(ctx, req.Email)
-	if existingUser != nil {
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
+	ctx, span := tracing.Start(ctx, "userService.Register")
+	defer span.End()
+
+	if err := validateUsername(req.Username); err != nil {
+		return nil, err
 	}
 
-	existingUser, _ = s.userRepo.GetByUsername(ctx, req.Username)
-	if existingUser != nil {
-		return nil, fmt.Errorf("username %s is already taken", req.Username)
+	// Check up front so a taken email or username is reported before hashing;
+	// the unique constraints still catch a concurrent signup. GetByUsername
+	// also finds usernames still held for their previous owner.
+	if _, err := s.userRepo.GetByEmail(ctx, req.Email); err == nil {
+		return nil, repository.ErrEmailTaken
+	} else if !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetByUsername(ctx, req.Username); err == nil {
+		return nil, repository.ErrUsernameTaken
+	} else if !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(ctx, req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -59,142 +105,193 @@ func (s *userService) Register(ctx context.Context, req *model.UserRequest) (*mo
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	metrics.Registration()
+
+	// The account is usable without verification, so a mail failure shouldn't fail signup;
+	// the user can request another link later.
+	if err := s.accountService.SendVerificationEmail(ctx, user.ID); err != nil {
+		slog.WarnContext(ctx, "failed to send verification email", "user_id", user.ID, "error", err)
+	}
 
-	// Return user response (without password)
-	return &model.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		FullName:  user.FullName,
-		Bio:       user.Bio,
-		Avatar:    user.Avatar,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return user.ToOwnerResponse(), nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *userService) Login(ctx context.Context, email, password string) (*model.UserResponse, string, error) {
+// Login authenticates a user and starts a new session. Accounts with two-factor
+// authentication get a challenge token instead and finish via TwoFactorService,
+// which records the outcome once the code is checked. Repeated failures from
+// an IP or against an account are throttled with a *LoginThrottledError, and
+// every attempt is kept in the login history.
+func (s *userService) Login(ctx context.Context, email, password string, client model.ClientInfo) (*model.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "userService.Login")
+	defer span.End()
+
+	now := time.Now()
+
+	// Refuse an IP that keeps failing before doing any work for it
+	if err := s.guard.checkIPThrottle(ctx, client.IPAddress, now); err != nil {
+		metrics.Login(metrics.LoginFailure)
+		return nil, err
+	}
+
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(ctx, email)
-	if err != nil {
-		return nil, "", fmt.Errorf("invalid email or password")
+	if err != nil || user.IsDeleted(now) {
+		// Spend the same bcrypt time as a wrong password so response times
+		// don't reveal which emails have accounts. An account past its
+		// deletion grace period is treated as gone.
+		_ = comparePassword(ctx, dummyPasswordHash, password)
+		metrics.Login(metrics.LoginFailure)
+		s.guard.recordAttempt(ctx, nil, client, false)
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.guard.checkAccount(ctx, user, client, now); err != nil {
+		metrics.Login(metrics.LoginFailure)
+		return nil, err
 	}
 
 	// Check password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, "", fmt.Errorf("invalid email or password")
+	if err := comparePassword(ctx, user.Password, password); err != nil {
+		metrics.Login(metrics.LoginFailure)
+		return nil, s.guard.recordFailure(ctx, user, client, now, ErrInvalidCredentials)
 	}
 
-	// Generate JWT token
-	token, err := s.generateJWT(user.ID)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	if err := checkRestrictions(user); err != nil {
+		metrics.Login(metrics.LoginFailure)
+		s.guard.recordAttempt(ctx, &user.ID, client, false)
+		return nil, err
 	}
 
-	// Return user response and token
-	userResponse := &model.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		FullName:  user.FullName,
-		Bio:       user.Bio,
-		Avatar:    user.Avatar,
-		CreatedAt: user.CreatedAt,
+	// The failure count is only reset once the second factor is checked too
+	if user.TOTPEnabledAt != nil {
+		challenge, err := s.authService.IssueChallenge(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate challenge: %w", err)
+		}
+		return &model.LoginResult{MFARequired: true, ChallengeToken: challenge}, nil
 	}
 
-	return userResponse, token, nil
-}
+	if err := cancelDeletion(ctx, s.userRepo, user, now); err != nil {
+		return nil, err
+	}
+	if err := s.guard.recordSuccess(ctx, user, client); err != nil {
+		return nil, err
+	}
 
-// GetProfile retrieves a user's profile
-func (s *userService) GetProfile(ctx context.Context, userID uuid.UUID) (*model.UserResponse, error) {
-	user, err := s.userRepo.GetByID(ctx, userID)
+	// Issue access and refresh tokens
+	tokens, err := s.authService.IssueTokens(ctx, user.ID, client)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
-	}
-
-	return &model.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		FullName:  user.FullName,
-		Bio:       user.Bio,
-		Avatar:    user.Avatar,
-		CreatedAt: user.CreatedAt,
-	}, nil
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	metrics.Login(metrics.LoginSuccess)
+	return &model.LoginResult{User: user.ToOwnerResponse(), Tokens: tokens}, nil
+}
+
+// ListLoginHistory retrieves a page of the user's login attempts, newest first
+func (s *userService) ListLoginHistory(ctx context.Context, userID uuid.UUID, page model.PageRequest) ([]*model.LoginAttempt, *model.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "userService.ListLoginHistory")
+	defer span.End()
+
+	return s.loginAttemptRepo.ListForUser(ctx, userID, page)
 }
 
-// UpdateProfile updates a user's profile information
-func (s *userService) UpdateProfile(ctx context.Context, userID uuid.UUID, updates map[string]interface{}) (*model.UserResponse, error) {
+// GetProfile retrieves a user's profile as seen by viewerID; pass uuid.Nil for
+// anonymous viewers. Banned users' profiles are hidden.
+func (s *userService) GetProfile(ctx context.Context, viewerID, userID uuid.UUID) (*model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "userService.GetProfile")
+	defer span.End()
+
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Update fields if provided
-	if fullName, ok := updates["full_name"]; ok {
-		user.FullName = fullName.(string)
-	}
-	if bio, ok := updates["bio"]; ok {
-		user.Bio = bio.(string)
+	return s.profile(ctx, viewerID, user)
+}
+
+// GetProfileByUsername retrieves a profile by username as seen by viewerID.
+// A username the user gave up still finds them while it's held for them.
+func (s *userService) GetProfileByUsername(ctx context.Context, viewerID uuid.UUID, username string) (*model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "userService.GetProfileByUsername")
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
-	if avatar, ok := updates["avatar"]; ok {
-		user.Avatar = avatar.(string)
+
+	return s.profile(ctx, viewerID, user)
+}
+
+// profile builds the user's profile with its counts. Only the owner sees
+// their email, and only other signed-in users get is_following.
+func (s *userService) profile(ctx context.Context, viewerID uuid.UUID, user *model.User) (*model.UserResponse, error) {
+	if user.IsBanned() {
+		return nil, repository.ErrUserNotFound
 	}
 
-	// Save changes
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to update profile: %w", err)
+	response := user.ToResponse()
+	if viewerID == user.ID {
+		response = user.ToOwnerResponse()
 	}
 
-	return &model.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		FullName:  user.FullName,
-		Bio:       user.Bio,
-		Avatar:    user.Avatar,
-		CreatedAt: user.CreatedAt,
-	}, nil
-}
+	followers, following, err := s.followRepo.CountFollows(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.postRepo.CountByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	response.PostCount, response.FollowerCount, response.FollowingCount = &posts, &followers, &following
 
-// generateJWT creates a JWT token for the user
-func (s *userService) generateJWT(userID uuid.UUID) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
-		"iat":     time.Now().Unix(),
+	if viewerID != uuid.Nil && viewerID != user.ID {
+		isFollowing, err := s.followRepo.IsFollowing(ctx, viewerID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		response.IsFollowing = &isFollowing
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return response, nil
 }
 
-// ValidateJWT validates and parses a JWT token
-func (s *userService) ValidateJWT(tokenString string) (uuid.UUID, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.jwtSecret), nil
-	})
+// UpdateProfile applies a merge patch to the user's profile. When ifUpdatedAt
+// is set the update is refused with ErrUserModified if the profile changed
+// since then, so concurrent edits can't silently overwrite each other.
+func (s *userService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *model.UpdateProfileRequest, ifUpdatedAt *time.Time) (*model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "userService.UpdateProfile")
+	defer span.End()
 
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return uuid.UUID{}, err
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if ifUpdatedAt != nil && !user.UpdatedAt.Equal(*ifUpdatedAt) {
+		return nil, repository.ErrUserModified
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			return uuid.UUID{}, fmt.Errorf("invalid user_id in token")
+	changed := req.FullName.Set || req.Bio.Set || req.Avatar.Set
+	if req.FullName.Set {
+		if strings.TrimSpace(req.FullName.Or("")) == "" {
+			return nil, ErrFullNameRequired
 		}
+		user.FullName = *req.FullName.Value
+	}
+	if req.Bio.Set {
+		user.Bio = req.Bio.Or("")
+	}
+	if req.Avatar.Set {
+		user.Avatar = req.Avatar.Or("")
+	}
 
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return uuid.UUID{}, fmt.Errorf("invalid user_id format: %w", err)
+	// An empty patch changes nothing, so there's nothing to write
+	if changed {
+		if err := s.userRepo.Update(ctx, user, ifUpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to update profile: %w", err)
 		}
-
-		return userID, nil
 	}
 
-	return uuid.UUID{}, fmt.Errorf("invalid token")
+	return s.profile(ctx, userID, user)
 }
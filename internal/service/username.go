@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/apperr"
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+var (
+	// ErrInvalidUsername is returned for a username that doesn't match usernamePattern
+	ErrInvalidUsername = apperr.Validation("invalid_username", "username must be 3 to 30 letters, digits or underscores")
+	// ErrUsernameReserved is returned for a username set aside for the platform
+	ErrUsernameReserved = apperr.Validation("username_reserved", "username is reserved")
+	// ErrUsernameUnchanged is returned when changing to the current username
+	ErrUsernameUnchanged = apperr.Validation("username_unchanged", "that is already your username")
+	// ErrUsernameChangeTooSoon is returned when changing the username again within the cooldown
+	ErrUsernameChangeTooSoon = apperr.Forbidden("username_change_cooldown", "username was changed too recently")
+)
+
+// usernamePattern is the format every new username must have
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// reservedUsernames can't be registered or changed to, in any letter case,
+// since they'd pass for staff or clash with routes
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true,
+	"staff": true, "moderator": true, "support": true, "help": true,
+	"security": true, "official": true, "api": true, "www": true,
+	"me": true, "settings": true, "login": true, "logout": true,
+	"signup": true, "register": true, "null": true, "undefined": true,
+}
+
+// UsernameConfig controls username changes. A user must wait ChangeCooldown
+// between changes, and a username they give up stays held for them for
+// HoldPeriod so nobody else can claim it while old links still point to them.
+type UsernameConfig struct {
+	ChangeCooldown time.Duration
+	HoldPeriod     time.Duration
+}
+
+// validateUsername checks a username someone wants to take
+func validateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	if reservedUsernames[strings.ToLower(username)] {
+		return ErrUsernameReserved
+	}
+	return nil
+}
+
+// ChangeUsername gives the user a new username. The old one stays held for
+// them, and keeps resolving to them, for the configured hold period.
+func (s *userService) ChangeUsername(ctx context.Context, userID uuid.UUID, username string) (*model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "userService.ChangeUsername")
+	defer span.End()
+
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Username == username {
+		return nil, ErrUsernameUnchanged
+	}
+
+	now := time.Now()
+	if user.UsernameChangedAt != nil && now.Before(user.UsernameChangedAt.Add(s.usernameCfg.ChangeCooldown)) {
+		return nil, ErrUsernameChangeTooSoon
+	}
+
+	// Taking back one of your own held usernames is fine; anyone else's isn't
+	owner, err := s.userRepo.GetByUsername(ctx, username)
+	switch {
+	case err == nil && owner.ID != userID:
+		return nil, repository.ErrUsernameTaken
+	case err != nil && !errors.Is(err, repository.ErrUserNotFound):
+		return nil, err
+	}
+
+	if err := s.userRepo.ChangeUsername(ctx, userID, username, now.Add(s.usernameCfg.HoldPeriod)); err != nil {
+		return nil, err
+	}
+
+	return s.GetProfile(ctx, userID, userID)
+}
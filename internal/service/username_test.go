@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/naval1525/Social_Media_Backend/internal/model"
+	"github.com/naval1525/Social_Media_Backend/internal/repository"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		want     error
+	}{
+		{"jane_doe", nil},
+		{"Jane99", nil},
+		{"abc", nil},
+		{strings.Repeat("a", 30), nil},
+		{"ab", ErrInvalidUsername},
+		{strings.Repeat("a", 31), ErrInvalidUsername},
+		{"jane.doe", ErrInvalidUsername},
+		{"jane doe", ErrInvalidUsername},
+		{"jané", ErrInvalidUsername},
+		{"", ErrInvalidUsername},
+		{"admin", ErrUsernameReserved},
+		{"Admin", ErrUsernameReserved},
+		{"SUPPORT", ErrUsernameReserved},
+		{"me", ErrInvalidUsername},
+		{"settings", ErrUsernameReserved},
+		{"admin_jane", nil},
+	}
+
+	for _, tt := range tests {
+		if err := validateUsername(tt.username); !errors.Is(err, tt.want) {
+			t.Errorf("validateUsername(%q) = %v, want %v", tt.username, err, tt.want)
+		}
+	}
+}
+
+// fakeUserRepo keeps users in memory for the lookups Register and
+// ChangeUsername make
+type fakeUserRepo struct {
+	repository.UserRepository
+	users     []*model.User
+	created   []*model.User
+	changedTo string
+	holdUntil time.Time
+}
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *model.User) error {
+	user.ID = uuid.New()
+	r.created = append(r.created, user)
+	return nil
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) ChangeUsername(ctx context.Context, id uuid.UUID, username string, holdUntil time.Time) error {
+	r.changedTo, r.holdUntil = username, holdUntil
+	return nil
+}
+
+type fakeAccountService struct {
+	AccountService
+}
+
+func (fakeAccountService) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
+type fakeFollowRepo struct {
+	repository.FollowRepository
+}
+
+func (fakeFollowRepo) CountFollows(ctx context.Context, userID uuid.UUID) (int, int, error) {
+	return 0, 0, nil
+}
+
+type fakePostRepo struct {
+	repository.PostRepository
+}
+
+func (fakePostRepo) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func TestChangeUsername(t *testing.T) {
+	const cooldown = 30 * 24 * time.Hour
+	const hold = 14 * 24 * time.Hour
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name      string
+		changedAt *time.Time
+		username  string
+		want      error
+	}{
+		{"first change", nil, "jane_new", nil},
+		{"after cooldown", ago(cooldown + time.Minute), "jane_new", nil},
+		{"within cooldown", ago(cooldown - time.Minute), "jane_new", ErrUsernameChangeTooSoon},
+		{"just changed", ago(0), "jane_new", ErrUsernameChangeTooSoon},
+		{"unchanged", nil, "jane", ErrUsernameUnchanged},
+		{"reserved", nil, "Admin", ErrUsernameReserved},
+		{"invalid", nil, "j", ErrInvalidUsername},
+		{"taken", nil, "bob", repository.ErrUsernameTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jane := &model.User{ID: uuid.New(), Username: "jane", UsernameChangedAt: tt.changedAt}
+			bob := &model.User{ID: uuid.New(), Username: "bob"}
+			repo := &fakeUserRepo{users: []*model.User{jane, bob}}
+			s := &userService{
+				userRepo:    repo,
+				followRepo:  fakeFollowRepo{},
+				postRepo:    fakePostRepo{},
+				usernameCfg: UsernameConfig{ChangeCooldown: cooldown, HoldPeriod: hold},
+			}
+
+			_, err := s.ChangeUsername(context.Background(), jane.ID, tt.username)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("ChangeUsername = %v, want %v", err, tt.want)
+			}
+
+			if tt.want != nil {
+				if repo.changedTo != "" {
+					t.Errorf("username changed to %q despite the error", repo.changedTo)
+				}
+				return
+			}
+			if repo.changedTo != tt.username {
+				t.Errorf("changed to %q, want %q", repo.changedTo, tt.username)
+			}
+			// The old username is held for the hold period from now
+			if d := repo.holdUntil.Sub(now.Add(hold)); d < 0 || d > time.Minute {
+				t.Errorf("holdUntil = %v, want about %v", repo.holdUntil, now.Add(hold))
+			}
+		})
+	}
+}
+
+func TestRegisterChecksUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		want     error
+	}{
+		{"valid", "jane_doe", nil},
+		{"reserved", "Admin", ErrUsernameReserved},
+		{"invalid characters", "jane.doe", ErrInvalidUsername},
+		{"too short", "jd", ErrInvalidUsername},
+		{"taken", "bob", repository.ErrUsernameTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepo{users: []*model.User{{ID: uuid.New(), Username: "bob", Email: "bob@example.com"}}}
+			s := &userService{userRepo: repo, accountService: fakeAccountService{}}
+
+			_, err := s.Register(context.Background(), &model.UserRequest{
+				Username: tt.username,
+				Email:    "jane@example.com",
+				Password: "secret",
+				FullName: "Jane Doe",
+			})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Register = %v, want %v", err, tt.want)
+			}
+
+			wantCreated := 0
+			if tt.want == nil {
+				wantCreated = 1
+			}
+			if len(repo.created) != wantCreated {
+				t.Errorf("created %d users, want %d", len(repo.created), wantCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key from the RFC 6238 appendix B test vectors
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	want, _ := Code(rfcSecret, 1)
+	got, err := Code(" "+strings.ToLower(rfcSecret)+" ", 1)
+	if err != nil || got != want {
+		t.Errorf("Code(lowercase) = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestCodeRejectsInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code with an invalid secret succeeded, want error")
+	}
+}
+
+func TestStepBoundaries(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want int64
+	}{
+		{0, 0},
+		{29, 0},
+		{30, 1},
+		{59, 1},
+		{60, 2},
+	}
+
+	for _, tt := range tests {
+		if got := Step(time.Unix(tt.unix, 0)); got != tt.want {
+			t.Errorf("Step(%d) = %d, want %d", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAtStepBoundaries(t *testing.T) {
+	// The code for step 10, which covers 300s to 329s
+	code, err := Code(rfcSecret, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		unix int64
+		skew int
+		ok   bool
+	}{
+		{"first second of step", 300, 0, true},
+		{"last second of step", 329, 0, true},
+		{"step before, no skew", 299, 0, false},
+		{"step after, no skew", 330, 0, false},
+		{"one step early, skew 1", 270, 1, true},
+		{"one step late, skew 1", 359, 1, true},
+		{"two steps early, skew 1", 269, 1, false},
+		{"two steps late, skew 1", 360, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, code, time.Unix(tt.unix, 0), tt.skew)
+			if ok != tt.ok {
+				t.Fatalf("Validate at %d with skew %d = %v, want %v", tt.unix, tt.skew, ok, tt.ok)
+			}
+			// The matched step is what callers store to reject replays
+			if ok && step != 10 {
+				t.Errorf("matched step = %d, want 10", step)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizesCode(t *testing.T) {
+	code, _ := Code(rfcSecret, 10)
+	at := time.Unix(300, 0)
+
+	tests := []struct {
+		code string
+		ok   bool
+	}{
+		{code, true},
+		{" " + code + " ", true},
+		{code[:3] + " " + code[3:], true},
+		{code[:5], false},
+		{code + "0", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := Validate(rfcSecret, tt.code, at, 0); ok != tt.ok {
+			t.Errorf("Validate(%q) = %v, want %v", tt.code, ok, tt.ok)
+		}
+	}
+}
+
+func TestValidateRejectsInvalidSecret(t *testing.T) {
+	if _, ok := Validate("not base32!", "123456", time.Unix(300, 0), 1); ok {
+		t.Error("Validate with an invalid secret succeeded")
+	}
+}
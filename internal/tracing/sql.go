@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapDriver returns a driver whose connections record a client span for every
+// statement executed within a traced context. Statements run outside a trace,
+// such as migrations at startup, are passed straight through.
+func WrapDriver(d driver.Driver) driver.Driver {
+	return tracedDriver{d}
+}
+
+type tracedDriver struct {
+	driver.Driver
+}
+
+func (d tracedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return tracedConn{conn}, nil
+}
+
+// tracedConn forwards the optional driver interfaces database/sql looks for,
+// falling back the way database/sql itself would when the wrapped
+// connection doesn't implement one
+type tracedConn struct {
+	driver.Conn
+}
+
+func (c tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := startQuery(ctx, query)
+	res, err := execer.ExecContext(ctx, query, args)
+	endQuery(span, err)
+	return res, err
+}
+
+func (c tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := startQuery(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	endQuery(span, err)
+	return rows, err
+}
+
+func (c tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("tracing: driver does not support transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// startQuery opens a span for query if ctx is part of a trace. The span is
+// named after the SQL operation and carries the statement text.
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, nil
+	}
+
+	statement := strings.Join(strings.Fields(query), " ")
+	operation := "query"
+	if i := strings.IndexByte(statement, ' '); i > 0 {
+		operation = strings.ToUpper(statement[:i])
+	} else if statement != "" {
+		operation = strings.ToUpper(statement)
+	}
+
+	return Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(statement),
+		),
+	)
+}
+
+// endQuery finishes a span from startQuery. ErrSkip only tells database/sql
+// to retry another way, so it isn't recorded as a failure.
+func endQuery(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if !errors.Is(err, driver.ErrSkip) {
+		RecordError(span, err)
+	}
+	span.End()
+}
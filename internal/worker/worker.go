@@ -0,0 +1,96 @@
+// Package worker runs periodic background jobs alongside the HTTP server and
+// stops them cleanly on shutdown.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/naval1525/Social_Media_Backend/internal/tracing"
+)
+
+// Job is background work run once at start and then every Interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner runs jobs, each in its own goroutine, until stopped. A job never
+// overlaps with itself.
+type Runner struct {
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRunner creates a runner for jobs. Nothing runs until Start.
+func NewRunner(jobs ...Job) *Runner {
+	return &Runner{jobs: jobs}
+}
+
+// Start launches the jobs. Their contexts are independent of any request or
+// signal and are only cancelled by Stop.
+func (r *Runner) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	for _, job := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(ctx, job)
+	}
+}
+
+// Stop cancels the jobs and waits for any in progress to return, giving up
+// when ctx is done
+func (r *Runner) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background jobs did not stop in time: %w", ctx.Err())
+	}
+}
+
+func (r *Runner) loop(ctx context.Context, job Job) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	// Checking ctx first keeps a tick that raced with Stop from starting another run
+	for ctx.Err() == nil {
+		run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
+
+// run executes one pass of job in its own trace, logging failures. Errors
+// caused by Stop cancelling the job are expected and not logged.
+func run(ctx context.Context, job Job) {
+	ctx, span := tracing.Start(ctx, "job "+job.Name)
+	defer span.End()
+
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "background job failed", "job", job.Name, "error", err)
+	}
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_follows_followed_id_created_at_id;
+DROP INDEX IF EXISTS idx_follows_follower_id_created_at_id;
+DROP INDEX IF EXISTS idx_comments_post_id_created_at_id;
@@ -0,0 +1,6 @@
+-- Keyset pagination orders every list by (created_at, id); these indexes
+-- cover that ordering within each list's filter column. Posts already have
+-- idx_posts_user_id_created_at_id from the feed migration.
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_created_at_id ON comments(post_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_follows_follower_id_created_at_id ON follows(follower_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_follows_followed_id_created_at_id ON follows(followed_id, created_at DESC, id DESC);
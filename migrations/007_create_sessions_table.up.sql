@@ -0,0 +1,18 @@
+-- Each row is one refresh token. Rotating a token marks it rotated and inserts
+-- its successor with the same family_id; presenting a rotated token again
+-- revokes the whole family.
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    user_agent TEXT DEFAULT '',
+    ip_address VARCHAR(64) DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    rotated_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
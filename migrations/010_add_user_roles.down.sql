@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_created_at_id;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,10 @@
+-- Roles are ordered user < moderator < admin; the permissions each grants live in model.Role.
+-- suspended_until blocks login until it passes.
+-- There is no signup path to staff roles; promote the first admin by hand:
+--   UPDATE users SET role = 'admin' WHERE email = 'you@example.com';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'moderator', 'admin'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMPTZ;
+
+-- The admin user list pages by (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users(created_at DESC, id DESC);
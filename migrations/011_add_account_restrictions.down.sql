@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS moderation_actions;
+ALTER TABLE users DROP COLUMN IF EXISTS banned_at;
@@ -0,0 +1,16 @@
+-- A ban lasts until lifted, unlike suspended_until which lapses on its own.
+-- Banned users' posts, comments and profiles are hidden, not deleted.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_at TIMESTAMPTZ;
+
+-- Every suspension, ban and reversal, with who did it and why
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('suspend', 'unsuspend', 'ban', 'unban')),
+    reason TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_user_id_created_at_id ON moderation_actions(user_id, created_at DESC, id DESC);
@@ -0,0 +1,19 @@
+-- Consecutive failed logins since the last success or lockout, which drive the
+-- backoff delay; reaching the threshold sets locked_until and starts over.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_failed_login_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+
+-- Every login attempt. user_id is NULL when the email matched no account;
+-- failures per IP are counted from here.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id_created_at_id ON login_attempts(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip_address_created_at ON login_attempts(ip_address, created_at) WHERE NOT success;
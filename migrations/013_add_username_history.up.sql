@@ -0,0 +1,15 @@
+-- When the username was last changed, for the change cooldown
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username_changed_at TIMESTAMPTZ;
+
+-- Usernames a user has given up. Each stays reserved for its old owner until
+-- held_until, and lookups by it resolve to that account in the meantime.
+CREATE TABLE IF NOT EXISTS username_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username VARCHAR(50) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    held_until TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_username_history_username_held_until ON username_history(username, held_until);
+CREATE INDEX IF NOT EXISTS idx_username_history_user_id ON username_history(user_id);
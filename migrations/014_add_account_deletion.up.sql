@@ -0,0 +1,6 @@
+-- When a self-service account deletion takes effect. Logging in before then
+-- clears it; afterwards a background job deletes the row, and the cascading
+-- foreign keys take the user's content with it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;